@@ -0,0 +1,40 @@
+package alarms
+
+import (
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/coinbase/step-asg-deployer/aws"
+)
+
+// DeleteAlarms accepts at most 100 names
+const maxDeleteAlarms = 100
+
+// ForPrefix returns all alarms with names starting with prefix, nil returns every alarm
+func ForPrefix(cwc aws.CWAPI, prefix *string) ([]*cloudwatch.MetricAlarm, error) {
+	all := []*cloudwatch.MetricAlarm{}
+	err := cwc.DescribeAlarmsPages(&cloudwatch.DescribeAlarmsInput{AlarmNamePrefix: prefix}, func(page *cloudwatch.DescribeAlarmsOutput, _ bool) bool {
+		all = append(all, page.MetricAlarms...)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+// Teardown deletes the alarms
+func Teardown(cwc aws.CWAPI, names []*string) error {
+	for start := 0; start < len(names); start += maxDeleteAlarms {
+		end := start + maxDeleteAlarms
+		if end > len(names) {
+			end = len(names)
+		}
+
+		if _, err := cwc.DeleteAlarms(&cloudwatch.DeleteAlarmsInput{AlarmNames: names[start:end]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
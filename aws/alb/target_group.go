@@ -37,20 +37,31 @@ func (s *TargetGroup) ServiceName() *string {
 
 // GetInstances return instances on the target group
 func GetInstances(albc aws.ALBAPI, arn *string, instances []string) (aws.Instances, error) {
-	healthOutput, err := albc.DescribeTargetHealth(createDescribeTargetHealthInput(arn, instances))
+	thds, err := DescribeTargetHealth(albc, arn, instances)
 
 	if err != nil {
 		return nil, err
 	}
 
 	tgInstances := aws.Instances{}
-	for _, thd := range healthOutput.TargetHealthDescriptions {
+	for _, thd := range thds {
 		tgInstances.AddTargetGroupInstance(thd)
 	}
 
 	return tgInstances, nil
 }
 
+// DescribeTargetHealth returns the health of the instances in the target group
+func DescribeTargetHealth(albc aws.ALBAPI, arn *string, instances []string) ([]*elbv2.TargetHealthDescription, error) {
+	healthOutput, err := albc.DescribeTargetHealth(createDescribeTargetHealthInput(arn, instances))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return healthOutput.TargetHealthDescriptions, nil
+}
+
 func createDescribeTargetHealthInput(arn *string, instances []string) *elbv2.DescribeTargetHealthInput {
 	awsInstances := []*elbv2.TargetDescription{}
 	for _, id := range instances {
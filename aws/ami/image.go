@@ -2,6 +2,8 @@ package ami
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/step-asg-deployer/aws"
@@ -10,8 +12,45 @@ import (
 
 // Image struct
 type Image struct {
-	ImageID       *string
-	DeployWithTag *string
+	ImageID         *string
+	CreationDate    *string
+	DeployWithTag   *string
+	ApprovedForTag  *string
+	OwnerID         *string
+	State           *string
+	Architecture    *string
+	DeprecationTime *string
+	RootEncrypted   bool
+}
+
+// Available returns true if the image can be launched
+func (im *Image) Available() bool {
+	return to.Strs(im.State) == ec2.ImageStateAvailable
+}
+
+// Deprecated returns true if the image has passed its deprecation time
+func (im *Image) Deprecated(now time.Time) bool {
+	if im.DeprecationTime == nil {
+		return false
+	}
+
+	deprecatedAt, err := time.Parse(time.RFC3339, *im.DeprecationTime)
+	if err != nil {
+		return true // Unknown time, safer to assume it is
+	}
+
+	return !now.Before(deprecatedAt)
+}
+
+// ApprovedFor returns true if the ApprovedFor tag includes the config
+// The tag is a comma separated list e.g. development,production
+func (im *Image) ApprovedFor(configName string) bool {
+	for _, approved := range strings.Split(to.Strs(im.ApprovedForTag), ",") {
+		if strings.TrimSpace(approved) == configName {
+			return true
+		}
+	}
+	return false
 }
 
 func isID(name string) bool {
@@ -22,12 +61,21 @@ func isID(name string) bool {
 	return (name)[0:4] == "ami-"
 }
 
-// Find takes either a ID or a Tag of an ami e.g. ubuntu or ami-00000000
-func Find(ec2c aws.EC2API, nameTagOrID *string) (*Image, error) {
-	if isID(*nameTagOrID) {
-		return findByID(ec2c, nameTagOrID)
+// Find takes a selector that is either a ID or a Tag of an ami e.g. ubuntu or ami-00000000
+// or a query of tags, owner and name
+func Find(ec2c aws.EC2API, selector *Selector) (*Image, error) {
+	if selector == nil {
+		return nil, fmt.Errorf("AMI Selector nil")
+	}
+
+	if selector.NameTagOrID == nil {
+		return findByQuery(ec2c, selector)
 	}
-	return findByTag(ec2c, nameTagOrID)
+
+	if isID(*selector.NameTagOrID) {
+		return findByID(ec2c, selector.NameTagOrID)
+	}
+	return findByTag(ec2c, selector.NameTagOrID)
 }
 
 func findByID(ec2c aws.EC2API, id *string) (*Image, error) {
@@ -49,6 +97,35 @@ func findByTag(ec2c aws.EC2API, nameTag *string) (*Image, error) {
 	return find(ec2c, &ec2.DescribeImagesInput{Filters: filters})
 }
 
+func findByQuery(ec2c aws.EC2API, selector *Selector) (*Image, error) {
+	output, err := ec2c.DescribeImages(selector.describeImagesInput())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.Images) > 1 && selector.MostRecent != nil && *selector.MostRecent {
+		im := newest(output.Images)
+		if im == nil {
+			return nil, fmt.Errorf("AMI Image nil")
+		}
+		return newImage(im), nil
+	}
+
+	switch len(output.Images) {
+	case 0:
+		return nil, nil
+	case 1:
+		im := output.Images[0]
+		if im == nil {
+			return nil, fmt.Errorf("AMI Image nil")
+		}
+		return newImage(im), nil
+	default:
+		return nil, fmt.Errorf("Must be exactly 1 Image matching %v, there are %v (set most_recent to choose the newest)", selector.String(), len(output.Images))
+	}
+}
+
 func find(ec2c aws.EC2API, in *ec2.DescribeImagesInput) (*Image, error) {
 	output, err := ec2c.DescribeImages(in)
 
@@ -64,11 +141,36 @@ func find(ec2c aws.EC2API, in *ec2.DescribeImagesInput) (*Image, error) {
 		if im == nil {
 			return nil, fmt.Errorf("AMI Image nil")
 		}
-		return &Image{
-			im.ImageId,
-			aws.FetchEc2Tag(im.Tags, to.Strp("DeployWith")),
-		}, nil
+		return newImage(im), nil
 	default:
 		return nil, fmt.Errorf("Must be exactly 1 Image with tag Name, there are %v", len(output.Images))
 	}
 }
+
+func newImage(im *ec2.Image) *Image {
+	return &Image{
+		ImageID:         im.ImageId,
+		CreationDate:    im.CreationDate,
+		DeployWithTag:   aws.FetchEc2Tag(im.Tags, to.Strp("DeployWith")),
+		ApprovedForTag:  aws.FetchEc2Tag(im.Tags, to.Strp("ApprovedFor")),
+		OwnerID:         im.OwnerId,
+		State:           im.State,
+		Architecture:    im.Architecture,
+		DeprecationTime: im.DeprecationTime,
+		RootEncrypted:   rootEncrypted(im),
+	}
+}
+
+// rootEncrypted returns true if the EBS root volume is encrypted
+func rootEncrypted(im *ec2.Image) bool {
+	for _, bdm := range im.BlockDeviceMappings {
+		if bdm == nil || bdm.Ebs == nil || bdm.DeviceName == nil {
+			continue
+		}
+
+		if *bdm.DeviceName == to.Strs(im.RootDeviceName) {
+			return bdm.Ebs.Encrypted != nil && *bdm.Ebs.Encrypted
+		}
+	}
+	return false
+}
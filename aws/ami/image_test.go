@@ -2,7 +2,9 @@ package ami
 
 import (
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/step-asg-deployer/aws/mocks"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
@@ -18,7 +20,7 @@ func Test_isID(t *testing.T) {
 func Test_Find_ID(t *testing.T) {
 	ec2c := &mocks.EC2Client{}
 	ec2c.AddImage("ubuntu", "ami-000000")
-	img, err := Find(ec2c, to.Strp("ami-000000"))
+	img, err := Find(ec2c, NewSelector("ami-000000"))
 	assert.NoError(t, err)
 	assert.Equal(t, "ami-000000", *img.ImageID)
 }
@@ -26,7 +28,39 @@ func Test_Find_ID(t *testing.T) {
 func Test_Find_Tag(t *testing.T) {
 	ec2c := &mocks.EC2Client{}
 	ec2c.AddImage("ubuntu", "ami-000000")
-	img, err := Find(ec2c, to.Strp("ubuntu"))
+	img, err := Find(ec2c, NewSelector("ubuntu"))
 	assert.NoError(t, err)
 	assert.Equal(t, "ami-000000", *img.ImageID)
 }
+
+func Test_Find_Attributes(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddImage("ubuntu", "ami-000000")
+	ec2c.DescribeImagesResp.Resp.Images[0].Tags = append(
+		ec2c.DescribeImagesResp.Resp.Images[0].Tags,
+		&ec2.Tag{Key: to.Strp("ApprovedFor"), Value: to.Strp("development, production")},
+	)
+
+	img, err := Find(ec2c, NewSelector("ubuntu"))
+	assert.NoError(t, err)
+	assert.Equal(t, "000000000000", *img.OwnerID)
+	assert.Equal(t, "x86_64", *img.Architecture)
+	assert.True(t, img.Available())
+	assert.True(t, img.RootEncrypted)
+	assert.True(t, img.ApprovedFor("production"))
+	assert.False(t, img.ApprovedFor("prod"))
+	assert.False(t, img.Deprecated(time.Now()))
+}
+
+func Test_Image_Deprecated(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	img := &Image{DeprecationTime: to.Strp("2019-12-01T00:00:00.000Z")}
+	assert.True(t, img.Deprecated(now))
+
+	img = &Image{DeprecationTime: to.Strp("2020-02-01T00:00:00.000Z")}
+	assert.False(t, img.Deprecated(now))
+
+	img = &Image{DeprecationTime: to.Strp("not a time")}
+	assert.True(t, img.Deprecated(now))
+}
@@ -0,0 +1,143 @@
+package ami
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Selector finds an image. It is either a string that is a Name tag or ID
+// e.g. "ubuntu" or "ami-00000000", or an object that queries images
+// e.g. {"tags": {"Team": "infra"}, "owner": "self", "name": "ubuntu-*", "most_recent": true}
+type Selector struct {
+	NameTagOrID *string `json:"-"`
+
+	Tags       map[string]string `json:"tags,omitempty"`
+	Owner      *string           `json:"owner,omitempty"` // Defaults to self
+	Name       *string           `json:"name,omitempty"`  // Image name, can include * wildcards
+	MostRecent *bool             `json:"most_recent,omitempty"`
+}
+
+// selectorQuery has the same fields without the custom JSON methods
+type selectorQuery Selector
+
+// NewSelector returns a selector for a Name tag or ID
+func NewSelector(nameTagOrID string) *Selector {
+	return &Selector{NameTagOrID: &nameTagOrID}
+}
+
+// UnmarshalJSON accepts a string or an object
+func (s *Selector) UnmarshalJSON(raw []byte) error {
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		*s = Selector{NameTagOrID: &str}
+		return nil
+	}
+
+	var q selectorQuery
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&q); err != nil {
+		return err
+	}
+
+	*s = Selector(q)
+	return nil
+}
+
+// MarshalJSON writes a Name tag or ID selector as a string
+func (s Selector) MarshalJSON() ([]byte, error) {
+	if s.NameTagOrID != nil {
+		return json.Marshal(*s.NameTagOrID)
+	}
+	return json.Marshal(selectorQuery(s))
+}
+
+// Validate returns an error if the selector cannot find an image
+func (s *Selector) Validate() error {
+	if s.NameTagOrID != nil {
+		if *s.NameTagOrID == "" {
+			return fmt.Errorf("must not be empty")
+		}
+		return nil
+	}
+
+	if len(s.Tags) == 0 && to.Strs(s.Name) == "" {
+		return fmt.Errorf("must define tags or name")
+	}
+
+	return nil
+}
+
+func (s *Selector) String() string {
+	if s.NameTagOrID != nil {
+		return *s.NameTagOrID
+	}
+
+	raw, _ := json.Marshal(selectorQuery(*s))
+	return string(raw)
+}
+
+func (s *Selector) describeImagesInput() *ec2.DescribeImagesInput {
+	filters := []*ec2.Filter{}
+
+	keys := []string{}
+	for key := range s.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		filters = append(filters, &ec2.Filter{
+			Name:   to.Strp(fmt.Sprintf("tag:%v", key)),
+			Values: []*string{to.Strp(s.Tags[key])},
+		})
+	}
+
+	if s.Name != nil {
+		filters = append(filters, &ec2.Filter{
+			Name:   to.Strp("name"),
+			Values: []*string{s.Name},
+		})
+	}
+
+	// An image still being created, e.g. a nightly bake, must not be the newest
+	filters = append(filters, &ec2.Filter{
+		Name:   to.Strp("state"),
+		Values: []*string{to.Strp("available")},
+	})
+
+	// Without an owner any public image matching the query could be chosen
+	owner := s.Owner
+	if owner == nil {
+		owner = to.Strp("self")
+	}
+
+	return &ec2.DescribeImagesInput{Filters: filters, Owners: []*string{owner}}
+}
+
+// newest returns the image with the latest CreationDate
+func newest(images []*ec2.Image) *ec2.Image {
+	sorted := []*ec2.Image{}
+	for _, im := range images {
+		if im != nil {
+			sorted = append(sorted, im)
+		}
+	}
+
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	// CreationDate is ISO 8601 so sorts as a string
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return to.Strs(sorted[i].CreationDate) > to.Strs(sorted[j].CreationDate)
+	})
+
+	return sorted[0]
+}
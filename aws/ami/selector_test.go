@@ -0,0 +1,97 @@
+package ami
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Selector_JSON(t *testing.T) {
+	var s Selector
+	assert.NoError(t, json.Unmarshal([]byte(`"ubuntu"`), &s))
+	assert.Equal(t, "ubuntu", *s.NameTagOrID)
+
+	raw, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `"ubuntu"`, string(raw))
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"tags": {"Team": "infra"}, "owner": "self", "most_recent": true}`), &s))
+	assert.Nil(t, s.NameTagOrID)
+	assert.Equal(t, "infra", s.Tags["Team"])
+	assert.Equal(t, "self", *s.Owner)
+	assert.True(t, *s.MostRecent)
+
+	raw, err = json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"tags":{"Team":"infra"},"owner":"self","most_recent":true}`, string(raw))
+
+	assert.Error(t, json.Unmarshal([]byte(`12`), &s))
+	assert.Error(t, json.Unmarshal([]byte(`{"tag": {"Team": "infra"}}`), &s))
+}
+
+func Test_Selector_Validate(t *testing.T) {
+	assert.NoError(t, NewSelector("ubuntu").Validate())
+	assert.Error(t, NewSelector("").Validate())
+	assert.Error(t, (&Selector{Owner: to.Strp("self")}).Validate())
+	assert.NoError(t, (&Selector{Name: to.Strp("ubuntu-*")}).Validate())
+	assert.NoError(t, (&Selector{Tags: map[string]string{"Team": "infra"}}).Validate())
+}
+
+func Test_Selector_describeImagesInput(t *testing.T) {
+	s := &Selector{
+		Tags:  map[string]string{"Team": "infra", "Base": "ubuntu"},
+		Owner: to.Strp("self"),
+		Name:  to.Strp("ubuntu-*"),
+	}
+
+	in := s.describeImagesInput()
+	assert.Equal(t, []string{"self"}, to.StrSlice(in.Owners))
+	assert.Equal(t, 4, len(in.Filters))
+	assert.Equal(t, "tag:Base", *in.Filters[0].Name)
+	assert.Equal(t, "tag:Team", *in.Filters[1].Name)
+	assert.Equal(t, "name", *in.Filters[2].Name)
+	assert.Equal(t, "state", *in.Filters[3].Name)
+	assert.Equal(t, []string{"available"}, to.StrSlice(in.Filters[3].Values))
+
+	// Public images are not found unless their owner is given
+	in = (&Selector{Name: to.Strp("ubuntu-*")}).describeImagesInput()
+	assert.Equal(t, []string{"self"}, to.StrSlice(in.Owners))
+}
+
+func Test_Find_Query_MostRecent(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddImage("ubuntu", "ami-000001")
+	ec2c.DescribeImagesResp.Resp.Images = []*ec2.Image{
+		&ec2.Image{ImageId: to.Strp("ami-000001"), State: to.Strp("available"), CreationDate: to.Strp("2018-05-01T00:00:00.000Z")},
+		&ec2.Image{ImageId: to.Strp("ami-000003"), State: to.Strp("available"), CreationDate: to.Strp("2018-05-03T00:00:00.000Z")},
+		&ec2.Image{ImageId: to.Strp("ami-000002"), State: to.Strp("available"), CreationDate: to.Strp("2018-05-02T00:00:00.000Z")},
+	}
+
+	s := &Selector{Tags: map[string]string{"Name": "ubuntu"}}
+	_, err := Find(ec2c, s)
+	assert.Error(t, err)
+
+	s.MostRecent = to.Boolp(true)
+	img, err := Find(ec2c, s)
+	assert.NoError(t, err)
+	assert.Equal(t, "ami-000003", *img.ImageID)
+	assert.Equal(t, "2018-05-03T00:00:00.000Z", *img.CreationDate)
+}
+
+func Test_Find_Query_MostRecent_Pending(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddImage("ubuntu", "ami-000001")
+	ec2c.DescribeImagesResp.Resp.Images = []*ec2.Image{
+		&ec2.Image{ImageId: to.Strp("ami-000001"), State: to.Strp("available"), CreationDate: to.Strp("2018-05-01T00:00:00.000Z")},
+		&ec2.Image{ImageId: to.Strp("ami-000002"), State: to.Strp("pending"), CreationDate: to.Strp("2018-05-02T00:00:00.000Z")},
+	}
+
+	// The nightly bake still in progress is not chosen
+	img, err := Find(ec2c, &Selector{Tags: map[string]string{"Name": "ubuntu"}, MostRecent: to.Boolp(true)})
+	assert.NoError(t, err)
+	assert.Equal(t, "ami-000001", *img.ImageID)
+}
@@ -0,0 +1,78 @@
+package asg
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Failed launch activities are either permanent, e.g. a bad AMI, that will
+// fail every time, or may succeed if retried, e.g. insufficient capacity.
+
+// permanentLaunchFailures match the status messages of EC2 errors that retrying will not fix
+var permanentLaunchFailures = []*regexp.Regexp{
+	regexp.MustCompile(`^The image id '\[?ami-[0-9a-f]+\]?' does not exist`),
+	regexp.MustCompile(`\bInvalidAMIID\.(NotFound|Malformed|Unavailable)\b`),
+	regexp.MustCompile(`^Invalid IamInstanceProfile: `),
+	regexp.MustCompile(`^Value \(.*\) for parameter iamInstanceProfile\.(name|arn) is invalid`),
+	regexp.MustCompile(`^The security group '.*' does not exist`),
+	regexp.MustCompile(`\bInvalidGroup\.NotFound\b`),
+	regexp.MustCompile(`^The key pair '.*' does not exist`),
+	regexp.MustCompile(`\bInvalidKeyPair\.NotFound\b`),
+	regexp.MustCompile(`\bInvalidBlockDeviceMapping\b`),
+	regexp.MustCompile(`^The instance configuration for this AWS Cloud is not supported`),
+	regexp.MustCompile(`^You are not authorized to perform this operation`),
+	regexp.MustCompile(`\bUnauthorizedOperation\b`),
+	regexp.MustCompile(`^You have requested more vCPU capacity than your current vCPU limit`),
+	regexp.MustCompile(`\b(InstanceLimitExceeded|VcpuLimitExceeded)\b`),
+}
+
+// zonalLaunchFailure matches failures in one Availability Zone, e.g. an instance type
+// not offered there, the ASG can launch in its other zones so they are not permanent
+var zonalLaunchFailure = regexp.MustCompile(`(?i)availability zone`)
+
+const launchDescription = "Launching a new EC2 instance"
+
+// maxActivities is the number of recent activities checked for launch failures
+const maxActivities = 20
+
+// FailedLaunches returns the failed launch activities of the ASG, most recent first
+func FailedLaunches(asgc aws.ASGAPI, asgName *string) ([]*autoscaling.Activity, error) {
+	activities, err := FailedActivities(asgc, asgName, maxActivities)
+	if err != nil {
+		return nil, err
+	}
+
+	launches := []*autoscaling.Activity{}
+	for _, activity := range activities {
+		if to.Strs(activity.StatusCode) != autoscaling.ScalingActivityStatusCodeFailed {
+			continue
+		}
+
+		if !strings.HasPrefix(to.Strs(activity.Description), launchDescription) {
+			continue
+		}
+
+		launches = append(launches, activity)
+	}
+
+	return launches, nil
+}
+
+// PermanentLaunchFailure returns true if retrying the launch will fail the same way
+func PermanentLaunchFailure(activity *autoscaling.Activity) bool {
+	message := to.Strs(activity.StatusMessage)
+	if zonalLaunchFailure.MatchString(message) {
+		return false
+	}
+
+	for _, failure := range permanentLaunchFailures {
+		if failure.MatchString(message) {
+			return true
+		}
+	}
+	return false
+}
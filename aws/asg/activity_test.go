@@ -0,0 +1,55 @@
+package asg
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FailedLaunches(t *testing.T) {
+	asgc := &mocks.ASGClient{}
+	asgc.AddActivity("Failed", "Terminating failed")
+	asgc.AddLaunchActivity("Successful", "")
+	asgc.AddLaunchActivity("Failed", "We currently do not have sufficient t2.small capacity in the Availability Zone you requested")
+
+	launches, err := FailedLaunches(asgc, to.Strp("asg"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(launches))
+	assert.False(t, PermanentLaunchFailure(launches[0]))
+}
+
+func Test_PermanentLaunchFailure(t *testing.T) {
+	permanent := []string{
+		"The image id '[ami-123456]' does not exist. Launching EC2 instance failed.",
+		"Invalid IamInstanceProfile: web-profile. Launching EC2 instance failed.",
+		"The instance configuration for this AWS Cloud is not supported. Launching EC2 instance failed.",
+		"The security group 'sg-123' does not exist. Launching EC2 instance failed.",
+		"You have requested more vCPU capacity than your current vCPU limit of 32 allows for the instance bucket",
+		"Value (web-profile) for parameter iamInstanceProfile.name is invalid. Invalid IAM Instance Profile name. Launching EC2 instance failed.",
+		"The key pair 'deploy' does not exist. Launching EC2 instance failed.",
+		"Client.InvalidAMIID.NotFound: The image id does not exist. Launching EC2 instance failed.",
+	}
+
+	for _, message := range permanent {
+		assert.True(t, PermanentLaunchFailure(&autoscaling.Activity{StatusMessage: to.Strp(message)}), message)
+	}
+
+	transient := []string{
+		"We currently do not have sufficient c5.large capacity in the Availability Zone you requested (us-east-1a).",
+		"There are not enough free addresses in subnet 'subnet-1' to satisfy the requested number of instances.",
+		"Request limit exceeded.",
+		// Only fails in one zone so it is counted against max_failed_launches
+		"Your requested instance type (m5.large) is not supported in your requested Availability Zone (us-east-1e). Please retry your request by not specifying an Availability Zone. Launching EC2 instance failed.",
+		"Value (us-east-1e) for parameter availabilityZone is invalid. Subnet 'subnet-1' is in the availability zone us-east-1e. Launching EC2 instance failed.",
+		// Mention an image or security group without being a missing one
+		"The image id '[ami-123456]' is pending. Launching EC2 instance failed.",
+		"Launching EC2 instance failed: security group rules are being updated.",
+	}
+
+	for _, message := range transient {
+		assert.False(t, PermanentLaunchFailure(&autoscaling.Activity{StatusMessage: to.Strp(message)}), message)
+	}
+}
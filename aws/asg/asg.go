@@ -2,10 +2,14 @@ package asg
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/alb"
+	"github.com/coinbase/step-asg-deployer/aws/elb"
 	"github.com/coinbase/step-asg-deployer/aws/lc"
 	"github.com/coinbase/step/utils/to"
 )
@@ -18,6 +22,7 @@ type ASG struct {
 	ReleaseIDTag   *string
 
 	DesiredCapacity *int64
+	CreatedTime     *time.Time
 
 	AutoScalingGroupName    *string
 	LaunchConfigurationName *string
@@ -54,6 +59,20 @@ func (s *ASG) ServiceID() *string {
 	return s.AutoScalingGroupName
 }
 
+// Owns returns true if the launch configuration or alarm name was created for this ASG,
+// launch configurations are named after the ASG and alarms <asg name>-<policy type>
+func (s *ASG) Owns(name string) bool {
+	if s.AutoScalingGroupName == nil {
+		return false
+	}
+
+	if name == *s.AutoScalingGroupName || name == to.Strs(s.LaunchConfigurationName) {
+		return true
+	}
+
+	return strings.HasPrefix(name, fmt.Sprintf("%v-", *s.AutoScalingGroupName))
+}
+
 //////
 // Init
 //////
@@ -72,6 +91,7 @@ func newASG(group *autoscaling.Group) *ASG {
 		TargetGroupARNs:   group.TargetGroupARNs,
 
 		DesiredCapacity: group.DesiredCapacity,
+		CreatedTime:     group.CreatedTime,
 
 		instances: group.Instances,
 	}
@@ -83,20 +103,106 @@ func newASG(group *autoscaling.Group) *ASG {
 
 // GetInstances returns all instances on an ASG
 func GetInstances(asgc aws.ASGAPI, asgName *string) (aws.Instances, error) {
-	group, err := findByName(asgc, asgName)
+	asgInstances, err := DescribeInstances(asgc, asgName)
 	if err != nil {
 		return nil, err
 	}
 
 	instances := aws.Instances{}
 
-	for _, i := range group.instances {
+	for _, i := range asgInstances {
 		instances.AddASGInstance(i)
 	}
 
 	return instances, nil
 }
 
+// Healthy returns true if the ASG has at least its desired capacity of healthy instances
+func (s *ASG) Healthy() bool {
+	instances := aws.Instances{}
+	for _, i := range s.instances {
+		instances.AddASGInstance(i)
+	}
+
+	healthy, _, _ := instances.HealthyUnhealthyTerming()
+
+	desired := 0
+	if s.DesiredCapacity != nil {
+		desired = int(*s.DesiredCapacity)
+	}
+
+	return healthy >= desired
+}
+
+// HealthyInLoadBalancers returns true if the ASG has at least its desired capacity of instances
+// healthy in the ASG and every ELB and Target Group it is attached to
+func (s *ASG) HealthyInLoadBalancers(elbc aws.ELBAPI, albc aws.ALBAPI) (bool, error) {
+	instances := aws.Instances{}
+	for _, i := range s.instances {
+		instances.AddASGInstance(i)
+	}
+
+	ids := instances.InstanceIDs()
+	if len(ids) > 0 {
+		for _, name := range s.LoadBalancerNames {
+			elbInstances, err := elb.GetInstances(elbc, name, ids)
+			if err != nil {
+				return false, err
+			}
+			instances = instances.MergeInstances(elbInstances)
+		}
+
+		for _, arn := range s.TargetGroupARNs {
+			tgInstances, err := alb.GetInstances(albc, arn, ids)
+			if err != nil {
+				return false, err
+			}
+			instances = instances.MergeInstances(tgInstances)
+		}
+	}
+
+	healthy, _, _ := instances.HealthyUnhealthyTerming()
+
+	desired := 0
+	if s.DesiredCapacity != nil {
+		desired = int(*s.DesiredCapacity)
+	}
+
+	return healthy >= desired, nil
+}
+
+// DescribeInstances returns the ASG's instances with their lifecycle and health states
+func DescribeInstances(asgc aws.ASGAPI, asgName *string) ([]*autoscaling.Instance, error) {
+	group, err := findByName(asgc, asgName)
+	if err != nil {
+		return nil, err
+	}
+
+	return group.instances, nil
+}
+
+// FailedActivities returns the most recent scaling activities that failed or were cancelled
+func FailedActivities(asgc aws.ASGAPI, asgName *string, max int64) ([]*autoscaling.Activity, error) {
+	out, err := asgc.DescribeScalingActivities(&autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: asgName,
+		MaxRecords:           to.Int64p(max),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	failed := []*autoscaling.Activity{}
+	for _, activity := range out.Activities {
+		switch to.Strs(activity.StatusCode) {
+		case autoscaling.ScalingActivityStatusCodeFailed, autoscaling.ScalingActivityStatusCodeCancelled:
+			failed = append(failed, activity)
+		}
+	}
+
+	return failed, nil
+}
+
 func findByName(asgc aws.ASGAPI, asgName *string) (*ASG, error) {
 	if asgName == nil {
 		return nil, fmt.Errorf("Autoscaling group not found beause nil name")
@@ -130,7 +236,7 @@ func findByName(asgc aws.ASGAPI, asgName *string) (*ASG, error) {
 // ForProjectConfigNotReleaseIDServiceMap finds all previous ASGs and returns them as a service map
 // Will error if there is an ASG without a service name || two ASGs for a service
 func ForProjectConfigNotReleaseIDServiceMap(asgc aws.ASGAPI, projectName *string, configName *string, releaseID *string) (map[string]*ASG, error) {
-	asgs, err := ForProjectConfigNOTReleaseID(asgc, projectName, configName, releaseID)
+	asgs, err := ForProjectConfigNOTReleaseID(asgc, projectName, configName, releaseID, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -154,17 +260,24 @@ func ForProjectConfigNotReleaseIDServiceMap(asgc aws.ASGAPI, projectName *string
 }
 
 // ForProjectConfigNOTReleaseID returns all ASGs not with the release ID
-func ForProjectConfigNOTReleaseID(asgc aws.ASGAPI, projectName *string, configName *string, releaseID *string) ([]*ASG, error) {
-	all, err := forProjectConfig(asgc, projectName, configName)
+// if serviceNames is not empty only ASGs for those services are returned
+func ForProjectConfigNOTReleaseID(asgc aws.ASGAPI, projectName *string, configName *string, releaseID *string, serviceNames []*string) ([]*ASG, error) {
+	all, err := ForProjectConfig(asgc, projectName, configName)
 	if err != nil {
 		return nil, err
 	}
 
 	asgs := []*ASG{}
 	for _, asg := range all {
-		if !aws.HasReleaseID(asg, releaseID) {
-			asgs = append(asgs, asg)
+		if aws.HasReleaseID(asg, releaseID) {
+			continue
 		}
+
+		if len(serviceNames) > 0 && !hasAnyServiceName(asg, serviceNames) {
+			continue
+		}
+
+		asgs = append(asgs, asg)
 	}
 
 	return asgs, nil
@@ -172,7 +285,7 @@ func ForProjectConfigNOTReleaseID(asgc aws.ASGAPI, projectName *string, configNa
 
 // ForProjectConfigReleaseID returns all ASGs with a release ID
 func ForProjectConfigReleaseID(asgc aws.ASGAPI, projectName *string, configName *string, releaseID *string) ([]*ASG, error) {
-	all, err := forProjectConfig(asgc, projectName, configName)
+	all, err := ForProjectConfig(asgc, projectName, configName)
 	if err != nil {
 		return nil, err
 	}
@@ -187,12 +300,30 @@ func ForProjectConfigReleaseID(asgc aws.ASGAPI, projectName *string, configName
 	return asgs, nil
 }
 
-func forProjectConfig(asgc aws.ASGAPI, projectName *string, configName *string) ([]*ASG, error) {
-	all, err := findInAws(asgc, &autoscaling.DescribeAutoScalingGroupsInput{})
+func hasAnyServiceName(asg *ASG, serviceNames []*string) bool {
+	for _, name := range serviceNames {
+		if aws.HasServiceName(asg, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForProjectConfig returns all ASGs for the project and config from any release
+// AWS filters on the tags so the whole account is not scanned
+func ForProjectConfig(asgc aws.ASGAPI, projectName *string, configName *string) ([]*ASG, error) {
+	all, err := findInAws(asgc, &autoscaling.DescribeAutoScalingGroupsInput{
+		Filters: []*autoscaling.Filter{
+			&autoscaling.Filter{Name: to.Strp("tag:ProjectName"), Values: []*string{projectName}},
+			&autoscaling.Filter{Name: to.Strp("tag:ConfigName"), Values: []*string{configName}},
+		},
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
+	// Double check the tags, never tear down an ASG from another project
 	asgs := []*ASG{}
 	for _, asg := range all {
 		if aws.HasProjectName(asg, projectName) && aws.HasConfigName(asg, configName) {
@@ -203,6 +334,11 @@ func forProjectConfig(asgc aws.ASGAPI, projectName *string, configName *string)
 	return asgs, nil
 }
 
+// All returns every ASG in the account and region
+func All(asgc aws.ASGAPI) ([]*ASG, error) {
+	return findInAws(asgc, &autoscaling.DescribeAutoScalingGroupsInput{})
+}
+
 func findInAws(asgc aws.ASGAPI, params *autoscaling.DescribeAutoScalingGroupsInput) ([]*ASG, error) {
 	allGroups := []*ASG{}
 
@@ -229,6 +365,39 @@ func findInAws(asgc aws.ASGAPI, params *autoscaling.DescribeAutoScalingGroupsInp
 	return allGroups, nil
 }
 
+//////////
+// Configure
+//////////
+
+// SuspendProcesses suspends the scaling processes e.g. AZRebalance
+func (s *ASG) SuspendProcesses(asgc aws.ASGAPI, processes []*string) error {
+	if len(processes) == 0 {
+		return nil
+	}
+
+	_, err := asgc.SuspendProcesses(&autoscaling.ScalingProcessQuery{
+		AutoScalingGroupName: s.ServiceID(),
+		ScalingProcesses:     processes,
+	})
+
+	return err
+}
+
+// EnableMetricsCollection enables group metrics, if metrics is empty all are enabled
+func (s *ASG) EnableMetricsCollection(asgc aws.ASGAPI, metrics []*string) error {
+	input := &autoscaling.EnableMetricsCollectionInput{
+		AutoScalingGroupName: s.ServiceID(),
+		Granularity:          to.Strp("1Minute"), // Only supported value
+	}
+
+	if len(metrics) > 0 {
+		input.Metrics = metrics
+	}
+
+	_, err := asgc.EnableMetricsCollection(input)
+	return err
+}
+
 //////////
 // Destruction
 //////////
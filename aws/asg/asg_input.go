@@ -52,9 +52,11 @@ func (s *Input) SetDefaults() {
 		s.LaunchConfigurationName = s.AutoScalingGroupName // Makes the name the same
 	}
 
-	s.HealthCheckType = to.Strp("EC2")
-	if len(s.LoadBalancerNames) > 0 || len(s.TargetGroupARNs) > 0 {
-		s.HealthCheckType = to.Strp("ELB") // If there are any ELBs set the health check to that
+	if s.HealthCheckType == nil {
+		s.HealthCheckType = to.Strp("EC2")
+		if len(s.LoadBalancerNames) > 0 || len(s.TargetGroupARNs) > 0 {
+			s.HealthCheckType = to.Strp("ELB") // If there are any ELBs set the health check to that
+		}
 	}
 
 	if len(s.TerminationPolicies) == 0 {
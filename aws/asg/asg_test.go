@@ -51,9 +51,9 @@ func Test_ForProjectConfigNotReleaseIDServiceMap_Error(t *testing.T) {
 }
 
 func Test_ForProjectConfigNOTReleaseID(t *testing.T) {
-	// func ForProjectConfigNOTReleaseID(asgc aws.ASGAPI, project_name *string, config_name *string, release_uuid *string) ([]*ASG, error) {
+	// func ForProjectConfigNOTReleaseID(asgc aws.ASGAPI, project_name *string, config_name *string, release_uuid *string, serviceNames []*string) ([]*ASG, error) {
 	asgc := &mocks.ASGClient{}
-	asgs, err := ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"))
+	asgs, err := ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(asgs))
 
@@ -63,11 +63,22 @@ func Test_ForProjectConfigNOTReleaseID(t *testing.T) {
 	asgc.AddPreviousRuntimeResources("not_project", "config", "service4", "release")
 	asgc.AddPreviousRuntimeResources("project", "not_config", "service5", "release")
 
-	asgs, err = ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"))
+	asgs, err = ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(asgs))
 }
 
+func Test_ForProjectConfigNOTReleaseID_ServiceNames(t *testing.T) {
+	asgc := &mocks.ASGClient{}
+	asgc.AddPreviousRuntimeResources("project", "config", "web", "old_release")
+	asgc.AddPreviousRuntimeResources("project", "config", "worker", "old_release")
+
+	asgs, err := ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"), []*string{to.Strp("worker")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(asgs))
+	assert.Equal(t, "worker", *asgs[0].ServiceName())
+}
+
 func Test_ForProjectConfigReleaseID(t *testing.T) {
 	// func ForProjectConfigReleaseID(asgc aws.ASGAPI, project_name *string, config_name *string, release_uuid *string) ([]*ASG, error) {
 	asgc := &mocks.ASGClient{}
@@ -92,10 +103,32 @@ func Test_Teardown(t *testing.T) {
 	cwc := &mocks.CWClient{}
 
 	asgc.AddPreviousRuntimeResources("project", "config", "service1", "not_release")
-	asgs, err := ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"))
+	asgs, err := ForProjectConfigNOTReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"), nil)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(asgs))
 
 	err = asgs[0].Teardown(asgc, cwc)
 	assert.NoError(t, err)
 }
+
+func Test_Configure(t *testing.T) {
+	// func (s *ASG) SuspendProcesses(asgc aws.ASGAPI, processes []*string) error {
+	// func (s *ASG) EnableMetricsCollection(asgc aws.ASGAPI, metrics []*string) error {
+	asgc := &mocks.ASGClient{}
+
+	asgc.AddPreviousRuntimeResources("project", "config", "service", "release")
+	asgs, err := ForProjectConfigReleaseID(asgc, to.Strp("project"), to.Strp("config"), to.Strp("release"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(asgs))
+
+	assert.NoError(t, asgs[0].SuspendProcesses(asgc, nil))
+	assert.Equal(t, 0, len(asgc.SuspendProcessesInputs))
+
+	assert.NoError(t, asgs[0].SuspendProcesses(asgc, []*string{to.Strp("AZRebalance")}))
+	assert.Equal(t, 1, len(asgc.SuspendProcessesInputs))
+
+	assert.NoError(t, asgs[0].EnableMetricsCollection(asgc, []*string{to.Strp("GroupMaxSize")}))
+	assert.Equal(t, 1, len(asgc.EnableMetricsCollectionInputs))
+	assert.Equal(t, "1Minute", *asgc.EnableMetricsCollectionInputs[0].Granularity)
+	assert.Equal(t, []string{"GroupMaxSize"}, to.StrSlice(asgc.EnableMetricsCollectionInputs[0].Metrics))
+}
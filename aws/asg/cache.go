@@ -0,0 +1,79 @@
+package asg
+
+import (
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step-asg-deployer/aws"
+)
+
+// CachedClient remembers the DescribeAutoScalingGroups pages for each input
+// so repeated lookups in one invocation only call AWS once.
+// Any change to an ASG through the client clears the cache.
+// It must not be kept between invocations as the ASGs change
+type CachedClient struct {
+	aws.ASGAPI
+	pages map[string][]*autoscaling.DescribeAutoScalingGroupsOutput
+}
+
+// Cached returns a CachedClient wrapping asgc
+func Cached(asgc aws.ASGAPI) *CachedClient {
+	return &CachedClient{
+		ASGAPI: asgc,
+		pages:  map[string][]*autoscaling.DescribeAutoScalingGroupsOutput{},
+	}
+}
+
+// DescribeAutoScalingGroupsPages returns the cached pages for the input or fetches them
+func (c *CachedClient) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAutoScalingGroupsInput, fn func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool) error {
+	key := input.String()
+
+	pages, ok := c.pages[key]
+	if !ok {
+		err := c.ASGAPI.DescribeAutoScalingGroupsPages(input, func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+			pages = append(pages, page)
+			return !lastPage
+		})
+
+		if err != nil {
+			return err
+		}
+
+		c.pages[key] = pages
+	}
+
+	for i, page := range pages {
+		if !fn(page, i == len(pages)-1) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Clear empties the cache
+func (c *CachedClient) Clear() {
+	c.pages = map[string][]*autoscaling.DescribeAutoScalingGroupsOutput{}
+}
+
+// CreateAutoScalingGroup clears the cache
+func (c *CachedClient) CreateAutoScalingGroup(input *autoscaling.CreateAutoScalingGroupInput) (*autoscaling.CreateAutoScalingGroupOutput, error) {
+	c.Clear()
+	return c.ASGAPI.CreateAutoScalingGroup(input)
+}
+
+// DeleteAutoScalingGroup clears the cache
+func (c *CachedClient) DeleteAutoScalingGroup(input *autoscaling.DeleteAutoScalingGroupInput) (*autoscaling.DeleteAutoScalingGroupOutput, error) {
+	c.Clear()
+	return c.ASGAPI.DeleteAutoScalingGroup(input)
+}
+
+// DetachLoadBalancers clears the cache
+func (c *CachedClient) DetachLoadBalancers(input *autoscaling.DetachLoadBalancersInput) (*autoscaling.DetachLoadBalancersOutput, error) {
+	c.Clear()
+	return c.ASGAPI.DetachLoadBalancers(input)
+}
+
+// DetachLoadBalancerTargetGroups clears the cache
+func (c *CachedClient) DetachLoadBalancerTargetGroups(input *autoscaling.DetachLoadBalancerTargetGroupsInput) (*autoscaling.DetachLoadBalancerTargetGroupsOutput, error) {
+	c.Clear()
+	return c.ASGAPI.DetachLoadBalancerTargetGroups(input)
+}
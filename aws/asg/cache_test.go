@@ -0,0 +1,104 @@
+package asg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Cached(t *testing.T) {
+	asgc := &mocks.ASGClient{}
+	asgc.AddPreviousRuntimeResources("project", "config", "service1", "release")
+	asgc.AddPreviousRuntimeResources("project", "config", "service2", "old_release")
+
+	cached := Cached(asgc)
+
+	asgs, err := ForProjectConfigReleaseID(cached, to.Strp("project"), to.Strp("config"), to.Strp("release"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(asgs))
+
+	asgs, err = ForProjectConfigNOTReleaseID(cached, to.Strp("project"), to.Strp("config"), to.Strp("release"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(asgs))
+
+	assert.Equal(t, 1, asgc.DescribeAutoScalingGroupsPagesCalls)
+
+	// Deleting an ASG clears the cache
+	assert.NoError(t, asgs[0].Teardown(cached, &mocks.CWClient{}))
+
+	_, err = ForProjectConfig(cached, to.Strp("project"), to.Strp("config"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, asgc.DescribeAutoScalingGroupsPagesCalls)
+}
+
+func Test_Cached_Filters(t *testing.T) {
+	asgc := &mocks.ASGClient{}
+	asgc.AddPreviousRuntimeResources("project", "config", "service1", "release")
+	asgc.AddPreviousRuntimeResources("other", "config", "service1", "release")
+
+	cached := Cached(asgc)
+
+	// AWS filters out other projects ASGs before the tags are double checked
+	asgs, err := findInAws(cached, &autoscaling.DescribeAutoScalingGroupsInput{
+		Filters: []*autoscaling.Filter{
+			&autoscaling.Filter{Name: to.Strp("tag:ProjectName"), Values: []*string{to.Strp("project")}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(asgs))
+	assert.Equal(t, "project", *asgs[0].ProjectNameTag)
+
+	// Different filters are not served from the same cache entry
+	asgs, err = ForProjectConfig(cached, to.Strp("other"), to.Strp("config"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(asgs))
+	assert.Equal(t, "other", *asgs[0].ProjectNameTag)
+
+	assert.Equal(t, 2, asgc.DescribeAutoScalingGroupsPagesCalls)
+}
+
+func Benchmark_Cached(b *testing.B) {
+	// The lookups a deploy makes for its project and config
+	lookups := func(b *testing.B, asgc aws.ASGAPI) {
+		project, config, release := to.Strp("project"), to.Strp("config"), to.Strp("release")
+		if _, err := ForProjectConfigReleaseID(asgc, project, config, release); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := ForProjectConfigNOTReleaseID(asgc, project, config, release, nil); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := ForProjectConfig(asgc, project, config); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	mockASGs := func() *mocks.ASGClient {
+		asgc := &mocks.ASGClient{}
+		for i := 0; i < 10; i++ {
+			asgc.AddPreviousRuntimeResources("project", "config", fmt.Sprintf("service%v", i), "release")
+			asgc.AddPreviousRuntimeResources("project", "config", fmt.Sprintf("service%v", i), "old_release")
+		}
+		return asgc
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		asgc := mockASGs()
+		for i := 0; i < b.N; i++ {
+			lookups(b, asgc)
+		}
+		b.Logf("%v DescribeAutoScalingGroupsPages calls per deploy", asgc.DescribeAutoScalingGroupsPagesCalls/b.N)
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		asgc := mockASGs()
+		for i := 0; i < b.N; i++ {
+			lookups(b, Cached(asgc))
+		}
+		b.Logf("%v DescribeAutoScalingGroupsPages calls per deploy", asgc.DescribeAutoScalingGroupsPagesCalls/b.N)
+	})
+}
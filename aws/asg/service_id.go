@@ -0,0 +1,41 @@
+package asg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// ServiceIDs are <project>-<config>-<service>-<TimeUUID>, launch configurations
+// are named after the ServiceID and alarms <ServiceID>-<policy type>
+
+// serviceIDSuffix matches the TimeUUID of a ServiceID and an optional policy type
+var serviceIDSuffix = regexp.MustCompile(fmt.Sprintf("^%v(-[a-z_]+)?$", timeUUIDPattern()))
+
+// timeUUIDPattern is built from a generated TimeUUID with its hex digits
+// replaced by a class so it always follows how to.TimeUUID generates ServiceIDs
+func timeUUIDPattern() string {
+	pattern := ""
+	for _, c := range *to.TimeUUID("") {
+		switch {
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+			pattern += "[0-9a-fA-F]"
+		default:
+			pattern += regexp.QuoteMeta(string(c))
+		}
+	}
+	return pattern
+}
+
+// IsServiceResource returns true if the name is a ServiceID of the service,
+// or the launch configuration or alarm of one
+func IsServiceResource(name string, projectName string, configName string, serviceName string) bool {
+	prefix := fmt.Sprintf("%v-%v-%v-", projectName, configName, serviceName)
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+
+	return serviceIDSuffix.MatchString(strings.TrimPrefix(name, prefix))
+}
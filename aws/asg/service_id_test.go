@@ -0,0 +1,21 @@
+package asg
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsServiceResource(t *testing.T) {
+	serviceID := *to.TimeUUID("project-config-web-")
+
+	assert.True(t, IsServiceResource(serviceID, "project", "config", "web"))
+	assert.True(t, IsServiceResource(serviceID+"-cpu_scale_up", "project", "config", "web"))
+
+	// Hand made and other configs resources
+	assert.False(t, IsServiceResource("project-config-web-manual", "project", "config", "web"))
+	assert.False(t, IsServiceResource(serviceID+"-manual-1", "project", "config", "web"))
+	assert.False(t, IsServiceResource(*to.TimeUUID("project-config-canary-web-"), "project", "config", "web"))
+	assert.False(t, IsServiceResource(*to.TimeUUID("project-config-web-api-"), "project", "config", "web"))
+}
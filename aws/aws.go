@@ -2,6 +2,7 @@ package aws
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
@@ -17,10 +18,16 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
 	ar "github.com/coinbase/step/aws"
 )
 
@@ -160,9 +167,18 @@ type IAMAPI iamiface.IAMAPI
 // SNSAPI aws API
 type SNSAPI snsiface.SNSAPI
 
+// SQSAPI aws API
+type SQSAPI sqsiface.SQSAPI
+
 // SFNAPI aws API
 type SFNAPI sfniface.SFNAPI
 
+// SSMAPI aws API
+type SSMAPI ssmiface.SSMAPI
+
+// SecretsManagerAPI aws API
+type SecretsManagerAPI secretsmanageriface.SecretsManagerAPI
+
 // Clients for AWS
 type Clients interface {
 	S3Client(region *string, accountID *string, role *string) S3API
@@ -173,13 +189,18 @@ type Clients interface {
 	CWClient(region *string, accountID *string, role *string) CWAPI
 	IAMClient(region *string, accountID *string, role *string) IAMAPI
 	SNSClient(region *string, accountID *string, role *string) SNSAPI
+	SQSClient(region *string, accountID *string, role *string) SQSAPI
 	SFNClient(region *string, accountID *string, role *string) SFNAPI
+	SSMClient(region *string, accountID *string, role *string) SSMAPI
+	SecretsManagerClient(region *string, accountID *string, role *string) SecretsManagerAPI
+	Retries() int64
 }
 
 // ClientsStr implementation
 type ClientsStr struct {
 	session *session.Session
 	configs map[string]*aws.Config
+	retryer *Retryer
 
 	S3  S3API
 	ASG ASGAPI
@@ -189,6 +210,9 @@ type ClientsStr struct {
 	CW  CWAPI
 	IAM IAMAPI
 	SNS SNSAPI
+	SQS SQSAPI
+	SSM SSMAPI
+	SM  SecretsManagerAPI
 }
 
 // GetSession get session
@@ -223,47 +247,79 @@ func (awsc *ClientsStr) SetConfig(key string, config *aws.Config) {
 	awsc.configs[key] = config
 }
 
+// config returns the config for region account and role with the shared Retryer
+func (awsc *ClientsStr) config(region *string, accountID *string, role *string) *aws.Config {
+	if awsc.retryer == nil {
+		awsc.retryer = NewRetryer()
+	}
+
+	return request.WithRetryer(ar.Config(awsc, region, accountID, role), awsc.retryer)
+}
+
+// Retries returns the number of AWS calls retried by all clients
+func (awsc *ClientsStr) Retries() int64 {
+	if awsc.retryer == nil {
+		return 0
+	}
+	return awsc.retryer.Retries()
+}
+
 // S3Client returns client for region account and role
 func (awsc *ClientsStr) S3Client(region *string, accountID *string, role *string) S3API {
-	return s3.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return s3.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // ASGClient returns client for region account and role
 func (awsc *ClientsStr) ASGClient(region *string, accountID *string, role *string) ASGAPI {
-	return autoscaling.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return autoscaling.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // ELBClient returns client for region account and role
 func (awsc *ClientsStr) ELBClient(region *string, accountID *string, role *string) ELBAPI {
-	return elb.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return elb.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // EC2Client returns client for region account and role
 func (awsc *ClientsStr) EC2Client(region *string, accountID *string, role *string) EC2API {
-	return ec2.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return ec2.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // ALBClient returns client for region account and role
 func (awsc *ClientsStr) ALBClient(region *string, accountID *string, role *string) ALBAPI {
-	return elbv2.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return elbv2.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // CWClient returns client for region account and role
 func (awsc *ClientsStr) CWClient(region *string, accountID *string, role *string) CWAPI {
-	return cloudwatch.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return cloudwatch.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // IAMClient returns client for region account and role
 func (awsc *ClientsStr) IAMClient(region *string, accountID *string, role *string) IAMAPI {
-	return iam.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return iam.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // SNSClient returns client for region account and role
 func (awsc *ClientsStr) SNSClient(region *string, accountID *string, role *string) SNSAPI {
-	return sns.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return sns.New(ar.Session(awsc), awsc.config(region, accountID, role))
+}
+
+// SQSClient returns client for region account and role
+func (awsc *ClientsStr) SQSClient(region *string, accountID *string, role *string) SQSAPI {
+	return sqs.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
 
 // SFNClient returns client for region account and role
 func (awsc *ClientsStr) SFNClient(region *string, accountID *string, role *string) SFNAPI {
-	return sfn.New(ar.Session(awsc), ar.Config(awsc, region, accountID, role))
+	return sfn.New(ar.Session(awsc), awsc.config(region, accountID, role))
+}
+
+// SSMClient returns client for region account and role
+func (awsc *ClientsStr) SSMClient(region *string, accountID *string, role *string) SSMAPI {
+	return ssm.New(ar.Session(awsc), awsc.config(region, accountID, role))
+}
+
+// SecretsManagerClient returns client for region account and role
+func (awsc *ClientsStr) SecretsManagerClient(region *string, accountID *string, role *string) SecretsManagerAPI {
+	return secretsmanager.New(ar.Session(awsc), awsc.config(region, accountID, role))
 }
@@ -0,0 +1,38 @@
+package console
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/step-asg-deployer/aws"
+)
+
+// Tail returns the last lines of the instance's console output,
+// the output is only available a few minutes after the instance boots
+func Tail(ec2c aws.EC2API, instanceID *string, lines int) (*string, error) {
+	out, err := ec2c.GetConsoleOutput(&ec2.GetConsoleOutputInput{
+		InstanceId: instanceID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Output == nil {
+		return nil, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(*out.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	all := strings.Split(strings.TrimRight(string(raw), "\r\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+
+	tail := strings.Join(all, "\n")
+	return &tail, nil
+}
@@ -0,0 +1,27 @@
+package console
+
+import (
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Tail(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+
+	out, err := Tail(ec2c, to.Strp("i-1"), 2)
+	assert.NoError(t, err)
+	assert.Nil(t, out)
+
+	ec2c.AddConsoleOutput("i-1", "boot\nstarting app\ncache warming\nerror: connection refused\n")
+
+	out, err = Tail(ec2c, to.Strp("i-1"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "cache warming\nerror: connection refused", *out)
+
+	out, err = Tail(ec2c, to.Strp("i-1"), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "boot\nstarting app\ncache warming\nerror: connection refused", *out)
+}
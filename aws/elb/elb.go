@@ -38,7 +38,7 @@ func (s *LoadBalancer) ServiceName() *string {
 
 // GetInstances returns a list of specific instances on the ELB
 func GetInstances(elbc aws.ELBAPI, name *string, instances []string) (aws.Instances, error) {
-	instanceStates, err := instanceStates(elbc, name, instances)
+	instanceStates, err := DescribeInstanceHealth(elbc, name, instances)
 
 	if err != nil {
 		return nil, err
@@ -64,7 +64,8 @@ func createDescribeInstanceHealthInput(name *string, instances []string) *aws_el
 	}
 }
 
-func instanceStates(elbc aws.ELBAPI, name *string, instances []string) ([]*aws_elb.InstanceState, error) {
+// DescribeInstanceHealth returns the states of the instances on the ELB
+func DescribeInstanceHealth(elbc aws.ELBAPI, name *string, instances []string) ([]*aws_elb.InstanceState, error) {
 
 	healthOutput, err := elbc.DescribeInstanceHealth(createDescribeInstanceHealthInput(name, instances))
 
@@ -0,0 +1,135 @@
+package gc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/alarms"
+	"github.com/coinbase/step-asg-deployer/aws/asg"
+	"github.com/coinbase/step-asg-deployer/aws/lc"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Orphans younger than MinMaxAge might belong to a release that is still deploying
+const (
+	DefaultMaxAge = 24 * time.Hour
+	MinMaxAge     = time.Hour
+)
+
+// Input to the scheduled GC task
+type Input struct {
+	MaxAge *int64 `json:"max_age,omitempty"` // seconds
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// MaxAgeDuration returns the max age or the default
+func (input *Input) MaxAgeDuration() time.Duration {
+	if input == nil || input.MaxAge == nil {
+		return DefaultMaxAge
+	}
+	return time.Duration(*input.MaxAge) * time.Second
+}
+
+// Report lists the orphaned launch configurations and alarms that were deleted
+type Report struct {
+	LaunchConfigurations []*string `json:"launch_configurations"`
+	Alarms               []*string `json:"alarms"`
+	Errors               []*string `json:"errors,omitempty"`
+	DryRun               bool      `json:"dry_run,omitempty"`
+}
+
+// Collect deletes launch configurations and alarms named after a service's ASGs
+// <project>-<config>-<service>-<uuid> that have no live ASG and are older than maxAge
+func Collect(asgc aws.ASGAPI, cwc aws.CWAPI, maxAge time.Duration, dryRun bool) (*Report, error) {
+	if maxAge < MinMaxAge {
+		return nil, fmt.Errorf("GC max age %v must be at least %v", maxAge, MinMaxAge)
+	}
+
+	groups, err := asg.All(asgc)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &orphans{groups: groups, before: time.Now().Add(-maxAge)}
+	report := &Report{LaunchConfigurations: []*string{}, Alarms: []*string{}, DryRun: dryRun}
+
+	configs, err := lc.All(asgc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, config := range configs {
+		if !o.orphaned(config.LaunchConfigurationName, config.CreatedTime) {
+			continue
+		}
+
+		if !dryRun {
+			if err := lc.Teardown(asgc, config.LaunchConfigurationName); err != nil {
+				report.Errors = append(report.Errors, to.Strp(err.Error()))
+				continue
+			}
+		}
+
+		report.LaunchConfigurations = append(report.LaunchConfigurations, config.LaunchConfigurationName)
+	}
+
+	metricAlarms, err := alarms.ForPrefix(cwc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanAlarms := []*string{}
+	for _, alarm := range metricAlarms {
+		if o.orphaned(alarm.AlarmName, alarm.AlarmConfigurationUpdatedTimestamp) {
+			orphanAlarms = append(orphanAlarms, alarm.AlarmName)
+		}
+	}
+
+	if !dryRun {
+		if err := alarms.Teardown(cwc, orphanAlarms); err != nil {
+			report.Errors = append(report.Errors, to.Strp(err.Error()))
+			return report, nil
+		}
+	}
+
+	report.Alarms = orphanAlarms
+
+	return report, nil
+}
+
+// orphans finds resources created for a deployer service that no longer has the ASG
+type orphans struct {
+	groups []*asg.ASG
+	before time.Time
+}
+
+func (o *orphans) orphaned(name *string, created *time.Time) bool {
+	// Without a time it cannot be known to be old enough
+	if name == nil || created == nil || !created.Before(o.before) {
+		return false
+	}
+
+	for _, group := range o.groups {
+		if group.Owns(*name) {
+			return false
+		}
+	}
+
+	return o.serviceResource(*name)
+}
+
+// serviceResource returns true if the name is a ServiceID, or a resource named after one, of a
+// service the deployer has an ASG for, so resources not created by the deployer are never deleted
+func (o *orphans) serviceResource(name string) bool {
+	for _, group := range o.groups {
+		if group.ProjectName() == nil || group.ConfigName() == nil || group.ServiceName() == nil {
+			continue
+		}
+
+		if asg.IsServiceResource(name, *group.ProjectName(), *group.ConfigName(), *group.ServiceName()) {
+			return true
+		}
+	}
+	return false
+}
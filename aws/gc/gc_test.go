@@ -0,0 +1,78 @@
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	orphanedID = *to.TimeUUID("project-config-web-")
+	newID      = *to.TimeUUID("project-config-web-")
+)
+
+func mockOrphans() (*mocks.ASGClient, *mocks.CWClient) {
+	asgc := &mocks.ASGClient{}
+	cwc := &mocks.CWClient{}
+	old := to.Timep(time.Now().Add(-48 * time.Hour))
+
+	// Live ASG, its launch configuration and alarm
+	live := asgc.AddPreviousRuntimeResources("project", "config", "web", "release-2")
+	cwc.AddAlarm(live + "-cpu_scale_up").AlarmConfigurationUpdatedTimestamp = old
+
+	// Orphans of a previous release
+	asgc.AddLaunchConfiguration(orphanedID).CreatedTime = old
+	cwc.AddAlarm(orphanedID + "-cpu_scale_up").AlarmConfigurationUpdatedTimestamp = old
+
+	// Too new, might be a release that is deploying
+	asgc.AddLaunchConfiguration(newID)
+	cwc.AddAlarm(newID + "-cpu_scale_up")
+
+	// Not created by the deployer
+	asgc.AddLaunchConfiguration("other-launch-config").CreatedTime = old
+	cwc.AddAlarm("other-alarm").AlarmConfigurationUpdatedTimestamp = old
+
+	// Has the service's prefix but is not named after a ServiceID
+	asgc.AddLaunchConfiguration("project-config-web-handmade").CreatedTime = old
+	cwc.AddAlarm("project-config-web-handmade-alarm").AlarmConfigurationUpdatedTimestamp = old
+
+	return asgc, cwc
+}
+
+func Test_Collect(t *testing.T) {
+	asgc, cwc := mockOrphans()
+
+	report, err := Collect(asgc, cwc, DefaultMaxAge, false)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{orphanedID}, to.StrSlice(report.LaunchConfigurations))
+	assert.Equal(t, []string{orphanedID + "-cpu_scale_up"}, to.StrSlice(report.Alarms))
+	assert.Equal(t, []string{orphanedID}, to.StrSlice(asgc.DeletedLaunchConfigurations))
+	assert.Equal(t, []string{orphanedID + "-cpu_scale_up"}, to.StrSlice(cwc.DeletedAlarms))
+}
+
+func Test_Collect_DryRun(t *testing.T) {
+	asgc, cwc := mockOrphans()
+
+	report, err := Collect(asgc, cwc, DefaultMaxAge, true)
+	assert.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, len(report.LaunchConfigurations))
+	assert.Equal(t, 1, len(report.Alarms))
+	assert.Nil(t, asgc.DeletedLaunchConfigurations)
+	assert.Nil(t, cwc.DeletedAlarms)
+}
+
+func Test_Collect_MaxAge(t *testing.T) {
+	asgc, cwc := mockOrphans()
+
+	_, err := Collect(asgc, cwc, time.Minute, false)
+	assert.Error(t, err)
+
+	assert.Equal(t, DefaultMaxAge, (&Input{}).MaxAgeDuration())
+	assert.Equal(t, 2*time.Hour, (&Input{MaxAge: to.Int64p(7200)}).MaxAgeDuration())
+}
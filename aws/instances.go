@@ -1,6 +1,8 @@
 package aws
 
 import (
+	"sort"
+
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elbv2"
@@ -10,6 +12,9 @@ const terminating = "terminating"
 const unhealthy = "unhealthy"
 const healthy = "healthy"
 
+// pendingHook instances are waiting for a launching lifecycle hook to be completed
+const pendingHook = "pending-hook"
+
 // Instances Map of instance id to state
 type Instances map[string]string
 
@@ -34,6 +39,10 @@ func (all Instances) AddASGInstance(i *autoscaling.Instance) {
 		state = healthy
 	}
 
+	if *i.LifecycleState == "Pending:Wait" || *i.LifecycleState == "Pending:Proceed" {
+		state = pendingHook
+	}
+
 	if (*i.LifecycleState)[0:4] == "Term" {
 		state = terminating
 	}
@@ -92,6 +101,29 @@ func (all Instances) TerminatingIDs() []string {
 	return terming
 }
 
+// PendingHookIDs list of instances waiting for a lifecycle hook
+func (all Instances) PendingHookIDs() []string {
+	pending := []string{}
+	for id, state := range all {
+		if state == pendingHook {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+// NotHealthyIDs sorted list of instances that are not healthy
+func (all Instances) NotHealthyIDs() []string {
+	ids := []string{}
+	for id, state := range all {
+		if state != healthy {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // MergeInstances merge new set of instances returns new set
 func (all Instances) MergeInstances(update Instances) Instances {
 	ret := Instances{}
@@ -103,7 +135,7 @@ func (all Instances) MergeInstances(update Instances) Instances {
 }
 
 func stateCompare(s1 string, s2 string) string {
-	// terminating > unhealthy > healthy
+	// terminating > pending-hook > unhealthy > healthy
 	if s1 == healthy && s2 == healthy {
 		// Both Healthy Return Healthy
 		return healthy
@@ -113,6 +145,87 @@ func stateCompare(s1 string, s2 string) string {
 		// Either Terming Return term
 		return terminating
 	}
+
+	if s1 == pendingHook || s2 == pendingHook {
+		// Not in service until the hook is complete
+		return pendingHook
+	}
 	// Otherwise Unhealthy
 	return unhealthy
 }
+
+//////////
+// Details
+//////////
+
+// InstanceDetail records the states of an instance to explain why it is not healthy
+type InstanceDetail struct {
+	LifecycleState *string                  `json:"lifecycle_state,omitempty"`
+	HealthStatus   *string                  `json:"health_status,omitempty"`
+	Targets        map[string]*TargetDetail `json:"targets,omitempty"`        // ELB name or target group to state
+	ConsoleOutput  *string                  `json:"console_output,omitempty"` // S3 path of the console output tail
+}
+
+// TargetDetail is the state of an instance in an ELB or target group
+type TargetDetail struct {
+	State       *string `json:"state,omitempty"`
+	ReasonCode  *string `json:"reason_code,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// InstanceDetails Map of instance id to detail
+type InstanceDetails map[string]*InstanceDetail
+
+func (all InstanceDetails) get(id string) *InstanceDetail {
+	detail, ok := all[id]
+	if !ok {
+		detail = &InstanceDetail{}
+		all[id] = detail
+	}
+	return detail
+}
+
+func (all InstanceDetails) addTarget(id string, name string, target *TargetDetail) {
+	detail := all.get(id)
+	if detail.Targets == nil {
+		detail.Targets = map[string]*TargetDetail{}
+	}
+	detail.Targets[name] = target
+}
+
+// AddASGInstance add a ASG instance detail
+func (all InstanceDetails) AddASGInstance(i *autoscaling.Instance) {
+	if i == nil || i.InstanceId == nil {
+		return
+	}
+
+	detail := all.get(*i.InstanceId)
+	detail.LifecycleState = i.LifecycleState
+	detail.HealthStatus = i.HealthStatus
+}
+
+// AddELBInstance add a ELB instance detail
+func (all InstanceDetails) AddELBInstance(name string, is *elb.InstanceState) {
+	if is == nil || is.InstanceId == nil {
+		return
+	}
+
+	all.addTarget(*is.InstanceId, name, &TargetDetail{
+		State:       is.State,
+		ReasonCode:  is.ReasonCode,
+		Description: is.Description,
+	})
+}
+
+// AddTargetGroupInstance add a target group instance detail
+func (all InstanceDetails) AddTargetGroupInstance(name string, thd *elbv2.TargetHealthDescription) {
+	if thd == nil || thd.Target == nil || thd.Target.Id == nil || thd.TargetHealth == nil {
+		return
+	}
+
+	all.addTarget(*thd.Target.Id, name, &TargetDetail{
+		State:       thd.TargetHealth.State,
+		ReasonCode:  thd.TargetHealth.Reason,
+		Description: thd.TargetHealth.Description,
+	})
+}
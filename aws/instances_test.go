@@ -3,6 +3,8 @@ package aws
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,3 +29,30 @@ func Test_MergeInstances(t *testing.T) {
 	i2 = Instances{"i": terminating}
 	assert.Equal(t, terminating, i2.MergeInstances(i1)["i"])
 }
+
+func Test_PendingHook(t *testing.T) {
+	all := Instances{}
+	all.AddASGInstance(&autoscaling.Instance{
+		InstanceId:     to.Strp("i-1"),
+		HealthStatus:   to.Strp("Healthy"),
+		LifecycleState: to.Strp("Pending:Wait"),
+	})
+	all.AddASGInstance(&autoscaling.Instance{
+		InstanceId:     to.Strp("i-2"),
+		HealthStatus:   to.Strp("Healthy"),
+		LifecycleState: to.Strp("InService"),
+	})
+
+	assert.Equal(t, pendingHook, all["i-1"])
+	assert.Equal(t, []string{"i-1"}, all.PendingHookIDs())
+
+	h, u, term := all.HealthyUnhealthyTerming()
+	assert.Equal(t, []int{1, 0, 0}, []int{h, u, term})
+
+	// An ELB cannot make a pending instance healthy
+	merged := all.MergeInstances(Instances{"i-1": healthy, "i-2": healthy})
+	assert.Equal(t, pendingHook, merged["i-1"])
+	assert.Equal(t, healthy, merged["i-2"])
+
+	assert.Equal(t, terminating, Instances{"i": pendingHook}.MergeInstances(Instances{"i": terminating})["i"])
+}
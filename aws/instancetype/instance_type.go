@@ -0,0 +1,52 @@
+package instancetype
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// InstanceType struct
+type InstanceType struct {
+	Name          *string
+	Architectures []*string
+}
+
+// Supports returns true if the instance type can run the architecture
+func (it *InstanceType) Supports(architecture *string) bool {
+	for _, arch := range it.Architectures {
+		if to.Strs(arch) == to.Strs(architecture) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns the instance type
+func Find(ec2c aws.EC2API, name *string) (*InstanceType, error) {
+	output, err := ec2c.DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []*string{name},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.InstanceTypes) != 1 || output.InstanceTypes[0] == nil {
+		return nil, fmt.Errorf("Instance Type %v not found", to.Strs(name))
+	}
+
+	info := output.InstanceTypes[0]
+
+	archs := []*string{}
+	if info.ProcessorInfo != nil {
+		archs = info.ProcessorInfo.SupportedArchitectures
+	}
+
+	return &InstanceType{
+		Name:          info.InstanceType,
+		Architectures: archs,
+	}, nil
+}
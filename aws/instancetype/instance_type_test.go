@@ -0,0 +1,22 @@
+package instancetype
+
+import (
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Find(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	_, err := Find(ec2c, to.Strp("m6g.large"))
+	assert.Error(t, err)
+
+	ec2c.AddInstanceType("m6g.large", "arm64")
+
+	it, err := Find(ec2c, to.Strp("m6g.large"))
+	assert.NoError(t, err)
+	assert.True(t, it.Supports(to.Strp("arm64")))
+	assert.False(t, it.Supports(to.Strp("x86_64")))
+}
@@ -1,6 +1,8 @@
 package lc
 
 import (
+	"strings"
+
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 
 	"github.com/coinbase/step-asg-deployer/aws"
@@ -18,3 +20,35 @@ func Teardown(asgc aws.ASGAPI, name *string) error {
 
 	return nil
 }
+
+// All returns every launch configuration in the account and region
+func All(asgc aws.ASGAPI) ([]*autoscaling.LaunchConfiguration, error) {
+	configs := []*autoscaling.LaunchConfiguration{}
+	err := asgc.DescribeLaunchConfigurationsPages(&autoscaling.DescribeLaunchConfigurationsInput{}, func(page *autoscaling.DescribeLaunchConfigurationsOutput, _ bool) bool {
+		configs = append(configs, page.LaunchConfigurations...)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+// ForPrefix returns the names of all launch configurations starting with prefix
+func ForPrefix(asgc aws.ASGAPI, prefix string) ([]*string, error) {
+	configs, err := All(asgc)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []*string{}
+	for _, config := range configs {
+		if config.LaunchConfigurationName != nil && strings.HasPrefix(*config.LaunchConfigurationName, prefix) {
+			names = append(names, config.LaunchConfigurationName)
+		}
+	}
+
+	return names, nil
+}
@@ -44,6 +44,9 @@ var ebsOptimizedInstances = map[string]bool{
 	"r4.16xlarge": true,
 }
 
+// DefaultDeviceName is the device of the EBS volume if one is not given
+const DefaultDeviceName = "/dev/xvda"
+
 // LaunchConfigInput input struct
 type LaunchConfigInput struct {
 	*autoscaling.CreateLaunchConfigurationInput
@@ -75,15 +78,22 @@ func (s *LaunchConfigInput) AddBlockDevice(ebsVolumeSize *int64, ebsVolumeType *
 	}
 
 	if ebsDeviceType == nil {
-		ebsDeviceType = to.Strp("/dev/xvda")
+		ebsDeviceType = to.Strp(DefaultDeviceName)
 	}
 
-	block := &autoscaling.BlockDeviceMapping{
+	s.AddBlockDeviceMapping(&autoscaling.BlockDeviceMapping{
 		DeviceName: ebsDeviceType,
 		Ebs: &autoscaling.Ebs{
 			VolumeSize: ebsVolumeSize,
 			VolumeType: ebsVolumeType,
 		},
+	})
+}
+
+// AddBlockDeviceMapping adds an EBS or instance store block device to the LC
+func (s *LaunchConfigInput) AddBlockDeviceMapping(block *autoscaling.BlockDeviceMapping) {
+	if block == nil {
+		return
 	}
 
 	if s.BlockDeviceMappings == nil {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_AddBlockDevice(t *testing.T) {
@@ -15,3 +16,18 @@ func Test_AddBlockDevice(t *testing.T) {
 	input.AddBlockDevice(to.Int64p(10), nil, to.Strp("asd"))
 
 }
+
+func Test_AddBlockDeviceMapping(t *testing.T) {
+	input := &LaunchConfigInput{&autoscaling.CreateLaunchConfigurationInput{}}
+
+	input.AddBlockDevice(to.Int64p(10), nil, nil)
+	input.AddBlockDeviceMapping(nil)
+	input.AddBlockDeviceMapping(&autoscaling.BlockDeviceMapping{
+		DeviceName:  to.Strp("/dev/sdb"),
+		VirtualName: to.Strp("ephemeral0"),
+	})
+
+	assert.Equal(t, 2, len(input.BlockDeviceMappings))
+	assert.Equal(t, DefaultDeviceName, *input.BlockDeviceMappings[0].DeviceName)
+	assert.Equal(t, "ephemeral0", *input.BlockDeviceMappings[1].VirtualName)
+}
@@ -15,7 +15,12 @@ type MockClients struct {
 	CW  *CWClient
 	IAM *IAMClient
 	SNS *SNSClient
+	SQS *SQSClient
 	SFN *mocks.MockSFNClient
+	SSM *SSMClient
+	SM  *SecretsManagerClient
+
+	RetryCount int64
 }
 
 // MockAWS mock clients
@@ -29,7 +34,10 @@ func MockAWS() *MockClients {
 		CW:  &CWClient{},
 		IAM: &IAMClient{},
 		SNS: &SNSClient{},
+		SQS: &SQSClient{},
 		SFN: &mocks.MockSFNClient{},
+		SSM: &SSMClient{},
+		SM:  &SecretsManagerClient{},
 	}
 }
 
@@ -73,7 +81,27 @@ func (a *MockClients) SNSClient(*string, *string, *string) aws.SNSAPI {
 	return a.SNS
 }
 
+// SQSClient returns
+func (a *MockClients) SQSClient(*string, *string, *string) aws.SQSAPI {
+	return a.SQS
+}
+
 // SFNClient returns
 func (a *MockClients) SFNClient(*string, *string, *string) aws.SFNAPI {
 	return a.SFN
 }
+
+// SSMClient returns
+func (a *MockClients) SSMClient(*string, *string, *string) aws.SSMAPI {
+	return a.SSM
+}
+
+// SecretsManagerClient returns
+func (a *MockClients) SecretsManagerClient(*string, *string, *string) aws.SecretsManagerAPI {
+	return a.SM
+}
+
+// Retries returns
+func (a *MockClients) Retries() int64 {
+	return a.RetryCount
+}
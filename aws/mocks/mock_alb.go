@@ -13,6 +13,8 @@ type ALBClient struct {
 	DescribeTargetGroupsResp map[string]*DescribeTargetGroupsResponse
 	DescribeTagsResp         map[string]*DescribeV2TagsResponse
 	DescribeTargetHealthResp map[string]*DescribeTargetHealthResponse
+
+	DescribeTargetHealthCalls int
 }
 
 // DescribeTargetGroupsResponse return
@@ -116,6 +118,7 @@ func (m *ALBClient) DescribeTags(in *elbv2.DescribeTagsInput) (*elbv2.DescribeTa
 // DescribeTargetHealth return
 func (m *ALBClient) DescribeTargetHealth(in *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
 	m.init()
+	m.DescribeTargetHealthCalls++
 	lbName := in.TargetGroupArn
 	resp := m.DescribeTargetHealthResp[*lbName]
 	if resp == nil {
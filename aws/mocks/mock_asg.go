@@ -2,6 +2,9 @@ package mocks
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/coinbase/step-asg-deployer/aws"
@@ -32,6 +35,20 @@ type ASGClient struct {
 	DescribeAutoScalingGroupsPageResp []DescribeAutoScalingGroupResponse
 	DescribeLaunchConfigurationsResp  map[string]*DescribeLaunchConfigurationsResponse
 	DescribePoliciesResp              map[string]*DescribePoliciesResponse
+
+	SuspendProcessesInputs        []*autoscaling.ScalingProcessQuery
+	EnableMetricsCollectionInputs []*autoscaling.EnableMetricsCollectionInput
+
+	Activities []*autoscaling.Activity
+
+	// DescribeScalingActivitiesErrors are returned for the ASG name
+	DescribeScalingActivitiesErrors map[string]error
+
+	DeletedASGs                 []*string
+	DeletedLaunchConfigurations []*string
+
+	DescribeAutoScalingGroupsPagesCalls int
+	DescribeScalingActivitiesCalls      int
 }
 
 func (m *ASGClient) init() {
@@ -144,6 +161,7 @@ func (m *ASGClient) AddPreviousRuntimeResources(projectName string, configName s
 // DescribeAutoScalingGroupsPages returns
 func (m *ASGClient) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAutoScalingGroupsInput, fn func(*autoscaling.DescribeAutoScalingGroupsOutput, bool) bool) error {
 	m.init()
+	m.DescribeAutoScalingGroupsPagesCalls++
 	// Loop through all autoscaling groups, 1 per page
 	var cont bool
 	for _, page := range m.DescribeAutoScalingGroupsPageResp {
@@ -151,7 +169,17 @@ func (m *ASGClient) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAu
 			return page.Error
 		}
 
-		cont = fn(page.Resp, false)
+		// Like AWS only the groups matching the tag filters are returned
+		groups := []*autoscaling.Group{}
+		if page.Resp != nil {
+			for _, group := range page.Resp.AutoScalingGroups {
+				if matchesTagFilters(group, input.Filters) {
+					groups = append(groups, group)
+				}
+			}
+		}
+
+		cont = fn(&autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: groups}, false)
 
 		if !cont {
 			return fmt.Errorf("Should always end here")
@@ -167,8 +195,36 @@ func (m *ASGClient) DescribeAutoScalingGroupsPages(input *autoscaling.DescribeAu
 	return nil
 }
 
+// matchesTagFilters returns true if the group has a tag matching every tag: filter
+func matchesTagFilters(group *autoscaling.Group, filters []*autoscaling.Filter) bool {
+	for _, filter := range filters {
+		if filter.Name == nil || !strings.HasPrefix(*filter.Name, "tag:") {
+			continue
+		}
+
+		key := strings.TrimPrefix(*filter.Name, "tag:")
+		matched := false
+		for _, tag := range group.Tags {
+			if to.Strs(tag.Key) != key {
+				continue
+			}
+			for _, value := range filter.Values {
+				if to.Strs(value) == to.Strs(tag.Value) {
+					matched = true
+				}
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
 // DeleteAutoScalingGroup returns
 func (m *ASGClient) DeleteAutoScalingGroup(input *autoscaling.DeleteAutoScalingGroupInput) (*autoscaling.DeleteAutoScalingGroupOutput, error) {
+	m.DeletedASGs = append(m.DeletedASGs, input.AutoScalingGroupName)
 	return nil, nil
 }
 
@@ -195,9 +251,43 @@ func (m *ASGClient) CreateLaunchConfiguration(input *autoscaling.CreateLaunchCon
 
 // DeleteLaunchConfiguration returns
 func (m *ASGClient) DeleteLaunchConfiguration(input *autoscaling.DeleteLaunchConfigurationInput) (*autoscaling.DeleteLaunchConfigurationOutput, error) {
+	m.DeletedLaunchConfigurations = append(m.DeletedLaunchConfigurations, input.LaunchConfigurationName)
 	return nil, nil
 }
 
+// AddLaunchConfiguration adds a launch configuration created now without an ASG
+func (m *ASGClient) AddLaunchConfiguration(name string) *autoscaling.LaunchConfiguration {
+	m.init()
+	config := &autoscaling.LaunchConfiguration{LaunchConfigurationName: to.Strp(name), CreatedTime: to.Timep(time.Now())}
+	m.DescribeLaunchConfigurationsResp[name] = &DescribeLaunchConfigurationsResponse{
+		Resp: &autoscaling.DescribeLaunchConfigurationsOutput{
+			LaunchConfigurations: []*autoscaling.LaunchConfiguration{config},
+		},
+	}
+	return config
+}
+
+// DescribeLaunchConfigurationsPages returns
+func (m *ASGClient) DescribeLaunchConfigurationsPages(in *autoscaling.DescribeLaunchConfigurationsInput, fn func(*autoscaling.DescribeLaunchConfigurationsOutput, bool) bool) error {
+	m.init()
+	names := []string{}
+	for name := range m.DescribeLaunchConfigurationsResp {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		resp := m.DescribeLaunchConfigurationsResp[name]
+		if resp.Error != nil {
+			return resp.Error
+		}
+		fn(resp.Resp, false)
+	}
+
+	fn(&autoscaling.DescribeLaunchConfigurationsOutput{}, true)
+	return nil
+}
+
 // DescribePolicies returns
 func (m *ASGClient) DescribePolicies(in *autoscaling.DescribePoliciesInput) (*autoscaling.DescribePoliciesOutput, error) {
 	m.init()
@@ -212,3 +302,44 @@ func (m *ASGClient) DescribePolicies(in *autoscaling.DescribePoliciesInput) (*au
 func (m *ASGClient) PutScalingPolicy(input *autoscaling.PutScalingPolicyInput) (*autoscaling.PutScalingPolicyOutput, error) {
 	return &autoscaling.PutScalingPolicyOutput{PolicyARN: to.Strp("arn")}, nil
 }
+
+// SuspendProcesses returns
+func (m *ASGClient) SuspendProcesses(input *autoscaling.ScalingProcessQuery) (*autoscaling.SuspendProcessesOutput, error) {
+	m.SuspendProcessesInputs = append(m.SuspendProcessesInputs, input)
+	return &autoscaling.SuspendProcessesOutput{}, nil
+}
+
+// EnableMetricsCollection returns
+func (m *ASGClient) EnableMetricsCollection(input *autoscaling.EnableMetricsCollectionInput) (*autoscaling.EnableMetricsCollectionOutput, error) {
+	m.EnableMetricsCollectionInputs = append(m.EnableMetricsCollectionInputs, input)
+	return &autoscaling.EnableMetricsCollectionOutput{}, nil
+}
+
+// AddActivity returns
+func (m *ASGClient) AddActivity(statusCode string, message string) {
+	m.Activities = append(m.Activities, &autoscaling.Activity{
+		StatusCode:    to.Strp(statusCode),
+		StatusMessage: to.Strp(message),
+	})
+}
+
+// AddLaunchActivity returns
+func (m *ASGClient) AddLaunchActivity(statusCode string, message string) {
+	m.Activities = append(m.Activities, &autoscaling.Activity{
+		Description:   to.Strp("Launching a new EC2 instance"),
+		StatusCode:    to.Strp(statusCode),
+		StatusMessage: to.Strp(message),
+	})
+}
+
+// DescribeScalingActivities returns
+func (m *ASGClient) DescribeScalingActivities(in *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	m.DescribeScalingActivitiesCalls++
+	if in.AutoScalingGroupName != nil {
+		if err, ok := m.DescribeScalingActivitiesErrors[*in.AutoScalingGroupName]; ok {
+			return nil, err
+		}
+	}
+
+	return &autoscaling.DescribeScalingActivitiesOutput{Activities: m.Activities}, nil
+}
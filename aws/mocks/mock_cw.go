@@ -1,17 +1,46 @@
 package mocks
 
 import (
+	"strings"
+	"time"
+
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
 )
 
 // CWClient struct
 type CWClient struct {
 	aws.CWAPI
+	Alarms        []*cloudwatch.MetricAlarm
+	DeletedAlarms []*string
+}
+
+// AddAlarm adds an alarm updated now
+func (m *CWClient) AddAlarm(name string) *cloudwatch.MetricAlarm {
+	alarm := &cloudwatch.MetricAlarm{
+		AlarmName:                          to.Strp(name),
+		AlarmConfigurationUpdatedTimestamp: to.Timep(time.Now()),
+	}
+	m.Alarms = append(m.Alarms, alarm)
+	return alarm
+}
+
+// DescribeAlarmsPages returns
+func (m *CWClient) DescribeAlarmsPages(input *cloudwatch.DescribeAlarmsInput, fn func(*cloudwatch.DescribeAlarmsOutput, bool) bool) error {
+	out := &cloudwatch.DescribeAlarmsOutput{}
+	for _, alarm := range m.Alarms {
+		if input.AlarmNamePrefix == nil || strings.HasPrefix(*alarm.AlarmName, *input.AlarmNamePrefix) {
+			out.MetricAlarms = append(out.MetricAlarms, alarm)
+		}
+	}
+	fn(out, true)
+	return nil
 }
 
 // DeleteAlarms returns
 func (m *CWClient) DeleteAlarms(input *cloudwatch.DeleteAlarmsInput) (*cloudwatch.DeleteAlarmsOutput, error) {
+	m.DeletedAlarms = append(m.DeletedAlarms, input.AlarmNames...)
 	return nil, nil
 }
 
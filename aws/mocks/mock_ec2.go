@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"encoding/base64"
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -32,12 +33,22 @@ type EC2Client struct {
 	DescribeSecurityGroupsResp map[string]*DescribeSecurityGroupsResponse
 	DescribeSubnetsResp        *DescribeSubnetsResponse
 	DescribeImagesResp         *DescribeImagesResponse
+	InstanceTypes              map[string]*ec2.InstanceTypeInfo
+	ConsoleOutputs             map[string]string
 }
 
 func (m *EC2Client) init() {
 	if m.DescribeSecurityGroupsResp == nil {
 		m.DescribeSecurityGroupsResp = map[string]*DescribeSecurityGroupsResponse{}
 	}
+
+	if m.InstanceTypes == nil {
+		m.InstanceTypes = map[string]*ec2.InstanceTypeInfo{}
+	}
+
+	if m.ConsoleOutputs == nil {
+		m.ConsoleOutputs = map[string]string{}
+	}
 }
 
 // AddSecurityGroup returns
@@ -53,13 +64,35 @@ func (m *EC2Client) AddSecurityGroup(name string, projectName string, configName
 	}
 }
 
+// AddSecurityGroupIngress adds an ingress rule to a security group
+func (m *EC2Client) AddSecurityGroupIngress(name string, permission *ec2.IpPermission) {
+	m.init()
+	resp := m.DescribeSecurityGroupsResp[name]
+	if resp == nil || resp.Resp == nil || len(resp.Resp.SecurityGroups) == 0 {
+		return
+	}
+
+	group := resp.Resp.SecurityGroups[0]
+	group.IpPermissions = append(group.IpPermissions, permission)
+}
+
 // AddImage returns
 func (m *EC2Client) AddImage(nameTag string, id string) {
 	m.DescribeImagesResp = &DescribeImagesResponse{
 		Resp: &ec2.DescribeImagesOutput{
 			Images: []*ec2.Image{
 				&ec2.Image{
-					ImageId: to.Strp(id),
+					ImageId:        to.Strp(id),
+					OwnerId:        to.Strp("000000000000"),
+					State:          to.Strp("available"),
+					Architecture:   to.Strp("x86_64"),
+					RootDeviceName: to.Strp("/dev/sda1"),
+					BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+						&ec2.BlockDeviceMapping{
+							DeviceName: to.Strp("/dev/sda1"),
+							Ebs:        &ec2.EbsBlockDevice{Encrypted: to.Boolp(true)},
+						},
+					},
 					Tags: []*ec2.Tag{
 						&ec2.Tag{Key: to.Strp("Name"), Value: to.Strp(nameTag)},
 						&ec2.Tag{Key: to.Strp("DeployWith"), Value: to.Strp("step-asg-deployer")},
@@ -70,6 +103,20 @@ func (m *EC2Client) AddImage(nameTag string, id string) {
 	}
 }
 
+// AddInstanceType returns
+func (m *EC2Client) AddInstanceType(name string, architectures ...string) {
+	m.init()
+	archs := []*string{}
+	for _, arch := range architectures {
+		archs = append(archs, to.Strp(arch))
+	}
+
+	m.InstanceTypes[name] = &ec2.InstanceTypeInfo{
+		InstanceType:  to.Strp(name),
+		ProcessorInfo: &ec2.ProcessorInfo{SupportedArchitectures: archs},
+	}
+}
+
 // AddSubnet returns
 func (m *EC2Client) AddSubnet(nameTag string, id string) {
 	m.DescribeSubnetsResp = &DescribeSubnetsResponse{
@@ -119,11 +166,71 @@ func (m *EC2Client) DescribeSubnets(in *ec2.DescribeSubnetsInput) (*ec2.Describe
 	return m.DescribeSubnetsResp.Resp, m.DescribeSubnetsResp.Error
 }
 
+// DescribeInstanceTypes returns
+func (m *EC2Client) DescribeInstanceTypes(in *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	m.init()
+	types := []*ec2.InstanceTypeInfo{}
+	for _, name := range in.InstanceTypes {
+		if info, ok := m.InstanceTypes[*name]; ok {
+			types = append(types, info)
+		}
+	}
+	return &ec2.DescribeInstanceTypesOutput{InstanceTypes: types}, nil
+}
+
 // DescribeImages returns
 func (m *EC2Client) DescribeImages(in *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
 	if m.DescribeImagesResp == nil {
 		return nil, fmt.Errorf("Add Image")
 	}
 
-	return m.DescribeImagesResp.Resp, m.DescribeImagesResp.Error
+	if m.DescribeImagesResp.Resp == nil {
+		return nil, m.DescribeImagesResp.Error
+	}
+
+	// Like AWS only the images in the filtered state are returned
+	images := []*ec2.Image{}
+	for _, image := range m.DescribeImagesResp.Resp.Images {
+		if matchesStateFilter(image, in.Filters) {
+			images = append(images, image)
+		}
+	}
+
+	return &ec2.DescribeImagesOutput{Images: images}, m.DescribeImagesResp.Error
+}
+
+func matchesStateFilter(image *ec2.Image, filters []*ec2.Filter) bool {
+	for _, filter := range filters {
+		if to.Strs(filter.Name) != "state" {
+			continue
+		}
+
+		for _, value := range filter.Values {
+			if to.Strs(value) == to.Strs(image.State) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// AddConsoleOutput returns
+func (m *EC2Client) AddConsoleOutput(instanceID string, output string) {
+	m.init()
+	m.ConsoleOutputs[instanceID] = output
+}
+
+// GetConsoleOutput returns
+func (m *EC2Client) GetConsoleOutput(in *ec2.GetConsoleOutputInput) (*ec2.GetConsoleOutputOutput, error) {
+	m.init()
+	output, ok := m.ConsoleOutputs[*in.InstanceId]
+	if !ok {
+		return &ec2.GetConsoleOutputOutput{InstanceId: in.InstanceId}, nil
+	}
+
+	return &ec2.GetConsoleOutputOutput{
+		InstanceId: in.InstanceId,
+		Output:     to.Strp(base64.StdEncoding.EncodeToString([]byte(output))),
+	}, nil
 }
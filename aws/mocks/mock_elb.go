@@ -31,6 +31,8 @@ type ELBClient struct {
 	DescribeLoadBalancersResp  map[string]*DescribeLoadBalancersResponse
 	DescribeTagsResp           map[string]*DescribeTagsResponse
 	DescribeInstanceHealthResp map[string]*DescribeInstanceHealthResponse
+
+	DescribeInstanceHealthCalls int
 }
 
 // AWSELBNotFoundError returns
@@ -116,6 +118,7 @@ func (m *ELBClient) DescribeTags(in *elb.DescribeTagsInput) (*elb.DescribeTagsOu
 // DescribeInstanceHealth returns
 func (m *ELBClient) DescribeInstanceHealth(in *elb.DescribeInstanceHealthInput) (*elb.DescribeInstanceHealthOutput, error) {
 	m.init()
+	m.DescribeInstanceHealthCalls++
 	lbName := in.LoadBalancerName
 	resp := m.DescribeInstanceHealthResp[*lbName]
 	if resp == nil {
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// SecretsManagerClient returns
+type SecretsManagerClient struct {
+	aws.SecretsManagerAPI
+	Secrets map[string]*secretsmanager.DescribeSecretOutput
+}
+
+func (m *SecretsManagerClient) init() {
+	if m.Secrets == nil {
+		m.Secrets = map[string]*secretsmanager.DescribeSecretOutput{}
+	}
+}
+
+// AddSecret returns
+func (m *SecretsManagerClient) AddSecret(name string) {
+	m.init()
+	m.Secrets[name] = &secretsmanager.DescribeSecretOutput{
+		Name: to.Strp(name),
+		ARN:  to.Strp(fmt.Sprintf("arn:aws:secretsmanager:us-east-1:000000000000:secret:%v", name)),
+	}
+}
+
+// DescribeSecret returns
+func (m *SecretsManagerClient) DescribeSecret(in *secretsmanager.DescribeSecretInput) (*secretsmanager.DescribeSecretOutput, error) {
+	m.init()
+	secret, ok := m.Secrets[*in.SecretId]
+	if !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "Secrets Manager can't find the specified secret", nil)
+	}
+	return secret, nil
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// SQSClient returns
+type SQSClient struct {
+	aws.SQSAPI
+	Queues map[string]*sqs.GetQueueUrlOutput
+}
+
+func (m *SQSClient) init() {
+	if m.Queues == nil {
+		m.Queues = map[string]*sqs.GetQueueUrlOutput{}
+	}
+}
+
+// AddQueue returns
+func (m *SQSClient) AddQueue(name string) {
+	m.init()
+	m.Queues[name] = &sqs.GetQueueUrlOutput{
+		QueueUrl: to.Strp(fmt.Sprintf("https://sqs.us-east-1.amazonaws.com/000000000000/%v", name)),
+	}
+}
+
+// GetQueueUrl returns
+func (m *SQSClient) GetQueueUrl(in *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error) {
+	m.init()
+	queue, ok := m.Queues[*in.QueueName]
+	if !ok {
+		return nil, awserr.New(sqs.ErrCodeQueueDoesNotExist, "The specified queue does not exist", nil)
+	}
+	return queue, nil
+}
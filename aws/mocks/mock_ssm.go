@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// SSMClient returns
+type SSMClient struct {
+	aws.SSMAPI
+	Parameters map[string]*ssm.ParameterMetadata
+}
+
+func (m *SSMClient) init() {
+	if m.Parameters == nil {
+		m.Parameters = map[string]*ssm.ParameterMetadata{}
+	}
+}
+
+// AddParameter returns
+func (m *SSMClient) AddParameter(name string) {
+	m.init()
+	m.Parameters[name] = &ssm.ParameterMetadata{
+		Name: to.Strp(name),
+		Type: to.Strp("SecureString"),
+	}
+}
+
+// DescribeParameters returns
+func (m *SSMClient) DescribeParameters(in *ssm.DescribeParametersInput) (*ssm.DescribeParametersOutput, error) {
+	m.init()
+	params := []*ssm.ParameterMetadata{}
+	for _, filter := range in.ParameterFilters {
+		for _, name := range filter.Values {
+			if p, ok := m.Parameters[*name]; ok {
+				params = append(params, p)
+			}
+		}
+	}
+	return &ssm.DescribeParametersOutput{Parameters: params}, nil
+}
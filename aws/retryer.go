@@ -0,0 +1,121 @@
+package aws
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Error classes, throttling and transient errors are retried permanent errors are not
+const (
+	ErrorThrottling = "throttling"
+	ErrorTransient  = "transient"
+	ErrorPermanent  = "permanent"
+)
+
+// ErrorClass returns the class of the error,
+// errors not from AWS might be a missing resource that is eventually consistent so are transient
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if request.IsErrorThrottle(err) {
+		return ErrorThrottling
+	}
+
+	if request.IsErrorRetryable(err) {
+		return ErrorTransient
+	}
+
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return ErrorTransient
+	}
+
+	if rerr, ok := aerr.(awserr.RequestFailure); ok && rerr.StatusCode() >= 500 {
+		return ErrorTransient
+	}
+
+	return ErrorPermanent
+}
+
+// IsPermanentError returns true if retrying the error will not help
+func IsPermanentError(err error) bool {
+	return ErrorClass(err) == ErrorPermanent
+}
+
+// Retryer retries throttling and transient errors with jittered exponential backoff
+// until the call has taken its Budget, it counts every retry
+type Retryer struct {
+	NumMaxRetries int
+	MinDelay      time.Duration
+	MinThrottle   time.Duration // Throttling backs off more
+	MaxDelay      time.Duration
+	Budget        time.Duration // Maximum time for a call including retries
+
+	retries int64
+}
+
+// NewRetryer returns a Retryer with the default delays and budget
+func NewRetryer() *Retryer {
+	return &Retryer{
+		NumMaxRetries: 8,
+		MinDelay:      100 * time.Millisecond,
+		MinThrottle:   500 * time.Millisecond,
+		MaxDelay:      10 * time.Second,
+		Budget:        30 * time.Second,
+	}
+}
+
+// MaxRetries returns the maximum number of retries
+func (r *Retryer) MaxRetries() int {
+	return r.NumMaxRetries
+}
+
+// ShouldRetry returns true if the error is not permanent and the budget is not spent
+func (r *Retryer) ShouldRetry(req *request.Request) bool {
+	if req.Retryable != nil {
+		return *req.Retryable
+	}
+
+	if ErrorClass(req.Error) == ErrorPermanent {
+		return false
+	}
+
+	return time.Since(req.Time)+r.delay(req) <= r.Budget
+}
+
+// RetryRules returns the jittered delay before the next retry, it is only called before a retry
+func (r *Retryer) RetryRules(req *request.Request) time.Duration {
+	atomic.AddInt64(&r.retries, 1)
+
+	delay := r.delay(req)
+	// Equal jitter, between half and all of the delay
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Retries returns the number of retries made
+func (r *Retryer) Retries() int64 {
+	return atomic.LoadInt64(&r.retries)
+}
+
+func (r *Retryer) delay(req *request.Request) time.Duration {
+	delay := r.MinDelay
+	if ErrorClass(req.Error) == ErrorThrottling {
+		delay = r.MinThrottle
+	}
+
+	for i := 0; i < req.RetryCount && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > r.MaxDelay {
+		return r.MaxDelay
+	}
+
+	return delay
+}
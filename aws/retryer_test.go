@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ErrorClass(t *testing.T) {
+	assert.Equal(t, "", ErrorClass(nil))
+	assert.Equal(t, ErrorThrottling, ErrorClass(awserr.New("Throttling", "Rate exceeded", nil)))
+	assert.Equal(t, ErrorTransient, ErrorClass(awserr.New("RequestError", "send request failed", nil)))
+	assert.Equal(t, ErrorTransient, ErrorClass(awserr.NewRequestFailure(awserr.New("InternalFailure", "", nil), 500, "id")))
+	assert.Equal(t, ErrorTransient, ErrorClass(fmt.Errorf("Autoscaling group asg not found")))
+	assert.Equal(t, ErrorPermanent, ErrorClass(awserr.New("ValidationError", "bad input", nil)))
+	assert.Equal(t, ErrorPermanent, ErrorClass(awserr.NewRequestFailure(awserr.New("AccessDenied", "", nil), 403, "id")))
+
+	assert.True(t, IsPermanentError(awserr.New("ValidationError", "bad input", nil)))
+	assert.False(t, IsPermanentError(awserr.New("Throttling", "Rate exceeded", nil)))
+}
+
+func Test_Retryer_ShouldRetry(t *testing.T) {
+	r := NewRetryer()
+	req := &request.Request{Time: time.Now()}
+
+	req.Error = awserr.New("Throttling", "Rate exceeded", nil)
+	assert.True(t, r.ShouldRetry(req))
+
+	req.Error = awserr.New("ValidationError", "bad input", nil)
+	assert.False(t, r.ShouldRetry(req))
+
+	// Out of budget
+	req.Error = awserr.New("Throttling", "Rate exceeded", nil)
+	req.Time = time.Now().Add(-r.Budget)
+	assert.False(t, r.ShouldRetry(req))
+}
+
+func Test_Retryer_RetryRules(t *testing.T) {
+	r := NewRetryer()
+	req := &request.Request{Error: awserr.New("RequestError", "send request failed", nil)}
+
+	delay := r.RetryRules(req)
+	assert.True(t, delay >= r.MinDelay/2 && delay <= r.MinDelay)
+
+	req.Error = awserr.New("Throttling", "Rate exceeded", nil)
+	req.RetryCount = 2
+	delay = r.RetryRules(req)
+	assert.True(t, delay >= 2*r.MinThrottle && delay <= 4*r.MinThrottle)
+
+	req.RetryCount = 100
+	assert.True(t, r.RetryRules(req) <= r.MaxDelay)
+
+	assert.Equal(t, int64(3), r.Retries())
+}
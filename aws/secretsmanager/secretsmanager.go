@@ -0,0 +1,28 @@
+package secretsmanager
+
+import (
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/coinbase/step-asg-deployer/aws"
+)
+
+// Secret struct
+type Secret struct {
+	Name *string
+	ARN  *string
+}
+
+// Find returns the secret with name, its value is never fetched
+func Find(smc aws.SecretsManagerAPI, name *string) (*Secret, error) {
+	output, err := smc.DescribeSecret(&secretsmanager.DescribeSecretInput{
+		SecretId: name,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Secret{
+		Name: output.Name,
+		ARN:  output.ARN,
+	}, nil
+}
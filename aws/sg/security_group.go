@@ -14,6 +14,49 @@ type SecurityGroup struct {
 	ConfigNameTag  *string
 	ServiceNameTag *string
 	GroupID        *string
+	IngressRules   []*IngressRule
+}
+
+// IngressRule is a single ingress permission of a security group
+type IngressRule struct {
+	Protocol    *string
+	FromPort    *int64
+	ToPort      *int64
+	CIDRs       []*string
+	GroupIDs    []*string
+	GroupOwners []*string
+}
+
+// AllPorts returns true if the rule opens every port
+func (r *IngressRule) AllPorts() bool {
+	if to.Strs(r.Protocol) == "-1" {
+		return true
+	}
+
+	if r.FromPort == nil || r.ToPort == nil {
+		return false
+	}
+
+	return *r.FromPort <= 0 && *r.ToPort >= 65535
+}
+
+// HasPort returns true if the rule opens the port
+func (r *IngressRule) HasPort(port int64) bool {
+	if r.AllPorts() {
+		return true
+	}
+
+	switch to.Strs(r.Protocol) {
+	case "tcp", "udp", "6", "17":
+	default:
+		return false // icmp uses the ports for type and code
+	}
+
+	if r.FromPort == nil || r.ToPort == nil {
+		return false
+	}
+
+	return *r.FromPort <= port && port <= *r.ToPort
 }
 
 // ProjectName returns tag
@@ -70,7 +113,38 @@ func newSGs(output []*ec2.SecurityGroup) []*SecurityGroup {
 			ProjectNameTag: aws.FetchEc2Tag(sg.Tags, to.Strp("ProjectName")),
 			ConfigNameTag:  aws.FetchEc2Tag(sg.Tags, to.Strp("ConfigName")),
 			ServiceNameTag: aws.FetchEc2Tag(sg.Tags, to.Strp("ServiceName")),
+			IngressRules:   newIngressRules(sg.IpPermissions),
 		})
 	}
 	return sgs
 }
+
+func newIngressRules(permissions []*ec2.IpPermission) []*IngressRule {
+	rules := []*IngressRule{}
+	for _, p := range permissions {
+		rule := &IngressRule{
+			Protocol:    p.IpProtocol,
+			FromPort:    p.FromPort,
+			ToPort:      p.ToPort,
+			CIDRs:       []*string{},
+			GroupIDs:    []*string{},
+			GroupOwners: []*string{},
+		}
+
+		for _, r := range p.IpRanges {
+			rule.CIDRs = append(rule.CIDRs, r.CidrIp)
+		}
+
+		for _, r := range p.Ipv6Ranges {
+			rule.CIDRs = append(rule.CIDRs, r.CidrIpv6)
+		}
+
+		for _, pair := range p.UserIdGroupPairs {
+			rule.GroupIDs = append(rule.GroupIDs, pair.GroupId)
+			rule.GroupOwners = append(rule.GroupOwners, pair.UserId)
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules
+}
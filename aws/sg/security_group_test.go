@@ -3,6 +3,7 @@ package sg
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/step-asg-deployer/aws/mocks"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
@@ -20,3 +21,46 @@ func Test_Find(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(sgs))
 }
+
+func Test_Find_IngressRules(t *testing.T) {
+	ec2c := &mocks.EC2Client{}
+	ec2c.AddSecurityGroup("sg1", "project_name", "config_name", "service_name", nil)
+	ec2c.AddSecurityGroupIngress("sg1", &ec2.IpPermission{
+		IpProtocol: to.Strp("tcp"),
+		FromPort:   to.Int64p(22),
+		ToPort:     to.Int64p(22),
+		IpRanges:   []*ec2.IpRange{&ec2.IpRange{CidrIp: to.Strp("0.0.0.0/0")}},
+		Ipv6Ranges: []*ec2.Ipv6Range{&ec2.Ipv6Range{CidrIpv6: to.Strp("::/0")}},
+		UserIdGroupPairs: []*ec2.UserIdGroupPair{
+			&ec2.UserIdGroupPair{GroupId: to.Strp("sg-other"), UserId: to.Strp("111111111111")},
+		},
+	})
+
+	sgs, err := Find(ec2c, []*string{to.Strp("sg1")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sgs[0].IngressRules))
+
+	rule := sgs[0].IngressRules[0]
+	assert.Equal(t, []string{"0.0.0.0/0", "::/0"}, to.StrSlice(rule.CIDRs))
+	assert.Equal(t, []string{"sg-other"}, to.StrSlice(rule.GroupIDs))
+	assert.Equal(t, []string{"111111111111"}, to.StrSlice(rule.GroupOwners))
+	assert.True(t, rule.HasPort(22))
+	assert.False(t, rule.HasPort(23))
+	assert.False(t, rule.AllPorts())
+}
+
+func Test_IngressRule_Ports(t *testing.T) {
+	all := &IngressRule{Protocol: to.Strp("-1")}
+	assert.True(t, all.AllPorts())
+	assert.True(t, all.HasPort(22))
+
+	tcpAll := &IngressRule{Protocol: to.Strp("tcp"), FromPort: to.Int64p(0), ToPort: to.Int64p(65535)}
+	assert.True(t, tcpAll.AllPorts())
+
+	rng := &IngressRule{Protocol: to.Strp("tcp"), FromPort: to.Int64p(20), ToPort: to.Int64p(30)}
+	assert.True(t, rng.HasPort(22))
+	assert.False(t, rng.HasPort(31))
+
+	icmp := &IngressRule{Protocol: to.Strp("icmp"), FromPort: to.Int64p(0), ToPort: to.Int64p(255)}
+	assert.False(t, icmp.HasPort(22))
+}
@@ -0,0 +1,15 @@
+package sqs
+
+import (
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/coinbase/step-asg-deployer/aws"
+)
+
+// QueueExists errors if SQS queue doesn't exists
+func QueueExists(sqsc aws.SQSAPI, queueName *string) error {
+	_, err := sqsc.GetQueueUrl(&sqs.GetQueueUrlInput{
+		QueueName: queueName,
+	})
+
+	return err
+}
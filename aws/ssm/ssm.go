@@ -0,0 +1,41 @@
+package ssm
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Parameter struct
+type Parameter struct {
+	Name *string
+	Type *string
+}
+
+// Find returns the parameter with name, its value is never fetched
+func Find(ssmc aws.SSMAPI, name *string) (*Parameter, error) {
+	output, err := ssmc.DescribeParameters(&ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{
+			&ssm.ParameterStringFilter{
+				Key:    to.Strp("Name"),
+				Option: to.Strp("Equals"),
+				Values: []*string{name},
+			},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.Parameters) != 1 {
+		return nil, fmt.Errorf("SSM Parameter %v not found", to.Strs(name))
+	}
+
+	return &Parameter{
+		Name: output.Parameters[0].Name,
+		Type: output.Parameters[0].Type,
+	}, nil
+}
@@ -6,7 +6,10 @@ import (
 	"io/ioutil"
 	"math"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
 	"github.com/coinbase/step-asg-deployer/deployer/models"
 	"github.com/coinbase/step/execution"
 	"github.com/coinbase/step/utils/is"
@@ -16,6 +19,9 @@ import (
 // executionPrefix returns
 func executionPrefix(release *models.Release) string {
 	pn := strings.Replace(*release.ProjectName, "/", "-", -1)
+	if release.Target != nil {
+		return fmt.Sprintf("deploy-%v-%v-%v-", pn, *release.ConfigName, strings.Replace(*release.Target, "/", "-", -1))
+	}
 	return fmt.Sprintf("deploy-%v-%v-", pn, *release.ConfigName)
 }
 
@@ -24,6 +30,49 @@ func executionName(release *models.Release) *string {
 	return to.TimeUUID(executionPrefix(release))
 }
 
+// runningReleaseID returns the ID of the release the running execution is deploying, nil if none is running
+func runningReleaseID(sfnc sfniface.SFNAPI, deployerARN *string, release *models.Release) (*string, error) {
+	prefix := executionPrefix(release)
+	input := &sfn.ListExecutionsInput{
+		StateMachineArn: deployerARN,
+		StatusFilter:    to.Strp(sfn.ExecutionStatusRunning),
+	}
+
+	for {
+		page, err := sfnc.ListExecutions(input)
+		if err != nil {
+			return nil, err
+		}
+
+		if page == nil {
+			return nil, nil
+		}
+
+		for _, exec := range page.Executions {
+			if exec.Name == nil || !strings.HasPrefix(*exec.Name, prefix) {
+				continue
+			}
+
+			desc, err := sfnc.DescribeExecution(&sfn.DescribeExecutionInput{ExecutionArn: exec.ExecutionArn})
+			if err != nil {
+				return nil, err
+			}
+
+			var running models.Release
+			if desc.Input == nil || json.Unmarshal([]byte(*desc.Input), &running) != nil || running.ReleaseID == nil {
+				return nil, fmt.Errorf("Cannot find the release ID of execution %v", *exec.Name)
+			}
+
+			return running.ReleaseID, nil
+		}
+
+		if page.NextToken == nil {
+			return nil, nil
+		}
+		input.NextToken = page.NextToken
+	}
+}
+
 // validateClientAttributes returns
 func validateClientAttributes(release *models.Release) error {
 	if release == nil {
@@ -32,20 +81,47 @@ func validateClientAttributes(release *models.Release) error {
 	}
 
 	if is.EmptyStr(release.ProjectName) {
-		return fmt.Errorf("ProjectName must be defined")
+		return &models.FieldError{Path: "project_name", Err: "ProjectName must be defined"}
 	}
 
 	if is.EmptyStr(release.ConfigName) {
-		return fmt.Errorf("ConfigName must be defined")
+		return &models.FieldError{Path: "config_name", Err: "ConfigName must be defined"}
 	}
 
 	if is.EmptyStr(release.Bucket) {
-		return fmt.Errorf("Bucket must be defined")
+		return &models.FieldError{Path: "bucket", Err: "Bucket must be defined"}
 	}
 
 	return nil
 }
 
+// validateRelease runs the deployers validations that do not need AWS
+// on a copy of the release, so errors are found before it is uploaded
+func validateRelease(jsonRaw []byte, region *string, accountID *string, identity *models.Identity) error {
+	var release models.Release
+	if err := json.Unmarshal(jsonRaw, &release); err != nil {
+		return err
+	}
+
+	release.SetDefaultRegionAccount(region, accountID)
+	release.SetDefaultBucket(identity, accountID)
+
+	// Attributes set by the client and server when deploying
+	release.SetUUID()
+	if is.EmptyStr(release.ReleaseID) {
+		release.ReleaseID = to.TimeUUID("release-")
+	}
+	release.CreatedAt = to.Timep(time.Now())
+
+	release.SetDefaults()
+
+	if err := release.ValidateAttributes(); err != nil {
+		return err
+	}
+
+	return release.ValidateServices()
+}
+
 func fileOrJSON(fileOrJSON *string) (*string, error) {
 	if fileOrJSON == nil {
 		return nil, fmt.Errorf("No file or JSON")
@@ -65,30 +141,26 @@ func fileOrJSON(fileOrJSON *string) (*string, error) {
 	return to.Strp(string(buf)), nil
 }
 
-func releaseFromFileOrJSON(releaseFileOrJSON *string, region *string, accountID *string) (*models.Release, error) {
-	jsonRaw, err := fileOrJSON(releaseFileOrJSON)
+func releaseFromFileOrJSON(releaseFileOrJSON *string, config *string, region *string, accountID *string, identity *models.Identity) (*models.Release, error) {
+	jsonRaw, locs, err := templateRelease(releaseFileOrJSON, config)
 	if err != nil {
 		return nil, err
 	}
 
 	var release models.Release
-	if err := json.Unmarshal([]byte(*jsonRaw), &release); err != nil {
+	if err := json.Unmarshal(jsonRaw, &release); err != nil {
 		return nil, err
 	}
 
-	// replace user_data_file with .userdata
-	if release.UserData != nil && *release.UserData == "{{USER_DATA_FILE}}" {
-		buf, err := ioutil.ReadFile(fmt.Sprintf("%v.userdata", *releaseFileOrJSON))
-		if err != nil {
-			return nil, err
-		}
-		release.UserData = to.Strp(string(buf))
-	}
-
 	release.SetDefaultRegionAccount(region, accountID)
+	release.SetDefaultBucket(identity, accountID)
 
 	if err := validateClientAttributes(&release); err != nil {
-		return nil, err
+		return nil, locs.locate(err)
+	}
+
+	if err := validateRelease(jsonRaw, region, accountID, identity); err != nil {
+		return nil, locs.locate(err)
 	}
 
 	return &release, nil
@@ -104,23 +176,6 @@ func stateName(sd *execution.StateDetails) string {
 	return stateName
 }
 
-func waiter(ed *execution.ExecutionDetails, sd *execution.StateDetails, err error) error {
-	if err != nil {
-		return fmt.Errorf("Unexpected Error %v", err.Error())
-	}
-
-	spinnerCounter++
-
-	ws, err := waiterStr(ed.Status, sd)
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("\r%v               ", ws)
-
-	return nil
-}
-
 func waiterStr(status *string, sd *execution.StateDetails) (string, error) {
 	newLine := fmt.Sprintf("%s(%s)", *status, stateName(sd))
 
@@ -163,6 +218,7 @@ func serviceStr(name string, service *models.Service) string {
 	GRAY := "\x1b[1;37m"
 	GREEN := "\x1b[0;32m"
 	YELLOW := "\x1b[1;33m"
+	BLUE := "\x1b[0;34m"
 	NC := "\x1b[0m" // No Color
 
 	if service.HealthReport != nil {
@@ -173,7 +229,11 @@ func serviceStr(name string, service *models.Service) string {
 
 		numberOfGreenDots := *service.HealthReport.Healthy
 		numberOfRedDots := *service.HealthReport.Terminating
-		numberOfYellowDots := *service.HealthReport.Launching - numberOfGreenDots - numberOfRedDots
+		numberOfBlueDots := 0
+		if service.HealthReport.PendingHook != nil {
+			numberOfBlueDots = *service.HealthReport.PendingHook
+		}
+		numberOfYellowDots := *service.HealthReport.Launching - numberOfGreenDots - numberOfBlueDots - numberOfRedDots
 
 		for i := 0; i < numberOfDots; i++ {
 			if i == barAt {
@@ -181,9 +241,11 @@ func serviceStr(name string, service *models.Service) string {
 			}
 			if i < numberOfGreenDots {
 				dots = append(dots, fmt.Sprintf("%v.%v", GREEN, NC))
-			} else if i < (numberOfGreenDots + numberOfYellowDots) {
+			} else if i < (numberOfGreenDots + numberOfBlueDots) {
+				dots = append(dots, fmt.Sprintf("%v.%v", BLUE, NC))
+			} else if i < (numberOfGreenDots + numberOfBlueDots + numberOfYellowDots) {
 				dots = append(dots, fmt.Sprintf("%v.%v", YELLOW, NC))
-			} else if i < (numberOfGreenDots + numberOfYellowDots + numberOfRedDots) {
+			} else if i < (numberOfGreenDots + numberOfBlueDots + numberOfYellowDots + numberOfRedDots) {
 				dots = append(dots, fmt.Sprintf("%v.%v", RED, NC))
 			} else {
 				dots = append(dots, fmt.Sprintf("%v.%v", GRAY, NC))
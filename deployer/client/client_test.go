@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/coinbase/step-asg-deployer/deployer/models"
@@ -47,7 +48,7 @@ func Test_releaseFromFileOrJSON(t *testing.T) {
         "security_groups": ["web-sg"]
       }
     }
-  }`), to.Strp("region"), to.Strp("account"))
+  }`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
 	assert.NoError(t, err)
 
 	assert.Equal(t, "rr", *release.ReleaseID)
@@ -55,17 +56,17 @@ func Test_releaseFromFileOrJSON(t *testing.T) {
 }
 
 func Test_releaseFromFileOrJSON_badRelease(t *testing.T) {
-	_, err := releaseFromFileOrJSON(to.Strp(`{}`), to.Strp("region"), to.Strp("account"))
+	_, err := releaseFromFileOrJSON(to.Strp(`{}`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
 	assert.Error(t, err)
 }
 
 func Test_releaseFromFileOrJSON_badJSON(t *testing.T) {
-	_, err := releaseFromFileOrJSON(to.Strp(`{`), to.Strp("region"), to.Strp("account"))
+	_, err := releaseFromFileOrJSON(to.Strp(`{`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
 	assert.Error(t, err)
 }
 
 func Test_releaseFromFileOrJSON_UnknownKey(t *testing.T) {
-	_, err := releaseFromFileOrJSON(to.Strp(`{"bad_key": "val"}`), to.Strp("region"), to.Strp("account"))
+	_, err := releaseFromFileOrJSON(to.Strp(`{"bad_key": "val"}`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
 	assert.Error(t, err)
 }
 
@@ -98,3 +99,83 @@ func Test_waiterStr(t *testing.T) {
 
 	waiterStrTest(t, r) // Checks errors
 }
+
+func Test_serviceStr_PendingHook(t *testing.T) {
+	r := minimalRelease(t)
+	r.Services["web"].HealthReport = &models.HealthReport{
+		TargetHealthy:  to.Intp(3),
+		TargetLaunched: to.Intp(4),
+		Healthy:        to.Intp(1),
+		Launching:      to.Intp(4),
+		Terminating:    to.Intp(0),
+		PendingHook:    to.Intp(2),
+	}
+
+	blue := "\x1b[0;34m.\x1b[0m"
+	yellow := "\x1b[1;33m.\x1b[0m"
+
+	str := serviceStr("web", r.Services["web"])
+	assert.Equal(t, 2, strings.Count(str, blue))
+	assert.Equal(t, 1, strings.Count(str, yellow))
+}
+
+func Test_releaseFromFileOrJSON_ErrorLocation(t *testing.T) {
+	_, err := releaseFromFileOrJSON(to.Strp(`{
+    "project_name": "project",
+    "config_name": "config",
+    "user_data": "echo DATE",
+    "services": {
+      "web": {
+        "instance_type": "t2.small",
+        "security_groups": ["web-sg"],
+        "autoscaling": { "spred": 0.5 }
+      }
+    }
+  }`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+
+	assert.Error(t, err)
+	assert.Equal(t, `release:9:26 services.web.autoscaling.spred: unknown field "spred"`, err.Error())
+
+	_, err = releaseFromFileOrJSON(to.Strp(`{
+    "project_name": "project",
+    "config_name": "config",
+    "user_data": "echo DATE",
+    "services": {
+      "web": {
+        "instance_type": "t2.small",
+        "security_groups": ["web-sg"],
+        "autoscaling": { "min_size": 2, "max_size": 1 }
+      }
+    }
+  }`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+
+	assert.Error(t, err)
+	assert.Equal(t, "release:9:26 services.web.autoscaling.min_size: Autoscaling MinSize is Greater than MaxSize", err.Error())
+}
+
+func Test_releaseFromFileOrJSON_AMISelector(t *testing.T) {
+	release, err := releaseFromFileOrJSON(to.Strp(`{
+    "project_name": "project",
+    "config_name": "config",
+    "ami": { "tags": { "Base": "ubuntu" }, "owner": "self", "most_recent": true },
+    "user_data": "echo DATE",
+    "services": {
+      "web": { "instance_type": "t2.small" }
+    }
+  }`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.NoError(t, err)
+	assert.Equal(t, "ubuntu", release.Image.Tags["Base"])
+	assert.True(t, *release.Image.MostRecent)
+
+	_, err = releaseFromFileOrJSON(to.Strp(`{
+    "project_name": "project",
+    "config_name": "config",
+    "ami": { "owner": "self" },
+    "user_data": "echo DATE",
+    "services": {
+      "web": { "instance_type": "t2.small" }
+    }
+  }`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.Error(t, err)
+	assert.Equal(t, "release:4:5 ami: AMI must define tags or name", err.Error())
+}
@@ -1,7 +1,8 @@
 package client
 
 import (
-	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
@@ -12,21 +13,34 @@ import (
 	"github.com/coinbase/step/utils/to"
 )
 
-// Deploy attempts to deploy release
-func Deploy(fileOrJSON *string) error {
+// Deploy attempts to deploy release with the deployer identity, if services is set only those services are deployed
+func Deploy(fileOrJSON *string, config *string, services *string, identity *models.Identity, output string) error {
 	region, accountID := to.RegionAccount()
-	release, err := releaseFromFileOrJSON(fileOrJSON, region, accountID)
+	release, err := releaseFromFileOrJSON(fileOrJSON, config, region, accountID, identity)
 	if err != nil {
 		return err
 	}
 
-	deployerARN := to.StepArn(region, accountID, to.Strp("coinbase-step-asg-deployer"))
+	if err := selectServices(release, services); err != nil {
+		return err
+	}
 
-	return deploy(&aws.ClientsStr{}, release, deployerARN)
+	deployerARN := identity.StateMachineARN(region, accountID)
+
+	if len(release.Targets) > 0 {
+		return deployTargets(&aws.ClientsStr{}, release, deployerARN, output, os.Stdout)
+	}
+
+	return deploy(&aws.ClientsStr{}, release, deployerARN, output)
 }
 
-func deploy(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+func deploy(awsc aws.Clients, release *models.Release, deployerARN *string, output string) error {
 	release.ReleaseID = to.TimeUUID("release-")
+	return deployWithProgress(awsc, release, deployerARN, newProgress(output))
+}
+
+// deployWithProgress deploys the release, its ReleaseID must already be set
+func deployWithProgress(awsc aws.Clients, release *models.Release, deployerARN *string, p *progress) error {
 	release.CreatedAt = to.Timep(time.Now())
 
 	// Uploading the Release to S3 to match SHAs
@@ -45,9 +59,22 @@ func deploy(awsc aws.Clients, release *models.Release, deployerARN *string) erro
 	}
 
 	// Execute every second
-	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
-	fmt.Println("")
-	return nil
+	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, p.waiter)
+	return p.finish()
+}
+
+// selectServices sets only_services from a comma separated list
+func selectServices(release *models.Release, services *string) error {
+	if services == nil || strings.TrimSpace(*services) == "" {
+		return nil
+	}
+
+	release.OnlyServices = []*string{}
+	for _, name := range strings.Split(*services, ",") {
+		release.OnlyServices = append(release.OnlyServices, to.Strp(strings.TrimSpace(name)))
+	}
+
+	return release.ValidateOnlyServices()
 }
 
 func findOrCreateExec(sfnc sfniface.SFNAPI, deployer *string, release *models.Release) (*execution.Execution, error) {
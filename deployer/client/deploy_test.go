@@ -13,6 +13,19 @@ func Test_Deploy(t *testing.T) {
 	r := minimalRelease(t)
 	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
 
-	err := deploy(awsc, r, to.Strp("deployerARN"))
+	err := deploy(awsc, r, to.Strp("deployerARN"), OutputText)
 	assert.NoError(t, err)
 }
+
+func Test_selectServices(t *testing.T) {
+	r := minimalRelease(t)
+	assert.NoError(t, selectServices(r, to.Strp("")))
+	assert.Nil(t, r.OnlyServices)
+
+	assert.NoError(t, selectServices(r, to.Strp(" web ")))
+	assert.Equal(t, []string{"web"}, to.StrSlice(r.OnlyServices))
+
+	err := selectServices(r, to.Strp("web,worker"))
+	assert.Error(t, err)
+	assert.Equal(t, "only_services[1]: OnlyServices includes unknown service worker", err.Error())
+}
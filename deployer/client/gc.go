@@ -0,0 +1,56 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/gc"
+)
+
+// GC deletes launch configurations and alarms left behind by deleted ASGs that are older than maxAge
+func GC(maxAge time.Duration, dryRun bool, output string) error {
+	return collect(&aws.ClientsStr{}, maxAge, dryRun, output, os.Stdout)
+}
+
+func collect(awsc aws.Clients, maxAge time.Duration, dryRun bool, output string, out io.Writer) error {
+	report, err := gc.Collect(awsc.ASGClient(nil, nil, nil), awsc.CWClient(nil, nil, nil), maxAge, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if output == OutputJSON {
+		raw, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(raw))
+	} else {
+		action := "Deleted"
+		if dryRun {
+			action = "Would delete"
+		}
+
+		for _, name := range report.LaunchConfigurations {
+			fmt.Fprintf(out, "%v launch configuration %v\n", action, *name)
+		}
+
+		for _, name := range report.Alarms {
+			fmt.Fprintf(out, "%v alarm %v\n", action, *name)
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		errs := []string{}
+		for _, e := range report.Errors {
+			errs = append(errs, *e)
+		}
+		return fmt.Errorf("GC errors: %v", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
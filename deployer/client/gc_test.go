@@ -0,0 +1,27 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step-asg-deployer/aws/gc"
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_collect(t *testing.T) {
+	awsc := mocks.MockAWS()
+	awsc.ASG.AddPreviousRuntimeResources("project", "config", "web", "release-2")
+	awsc.ASG.AddLaunchConfiguration("project-config-web-release-1").CreatedTime = to.Timep(time.Now().Add(-48 * time.Hour))
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, collect(awsc, gc.DefaultMaxAge, true, OutputText, out))
+	assert.Equal(t, "Would delete launch configuration project-config-web-release-1\n", out.String())
+	assert.Nil(t, awsc.ASG.DeletedLaunchConfigurations)
+
+	out = &bytes.Buffer{}
+	assert.NoError(t, collect(awsc, gc.DefaultMaxAge, false, OutputJSON, out))
+	assert.Equal(t, `{"launch_configurations":["project-config-web-release-1"],"alarms":[]}`+"\n", out.String())
+}
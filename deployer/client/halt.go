@@ -9,20 +9,71 @@ import (
 	"github.com/coinbase/step/utils/to"
 )
 
-// Halt attempts to halt release
-func Halt(fileOrJSON *string) error {
+// Halt attempts to halt release, if services is set only releases deploying one of them are halted
+func Halt(fileOrJSON *string, config *string, services *string, request *models.HaltRequest, identity *models.Identity, output string) error {
 	region, accountID := to.RegionAccount()
-	release, err := releaseFromFileOrJSON(fileOrJSON, region, accountID)
+	release, err := releaseFromFileOrJSON(fileOrJSON, config, region, accountID, identity)
 	if err != nil {
 		return err
 	}
 
-	deployerARN := to.StepArn(region, accountID, to.Strp("coinbase-step-asg-deployer"))
+	// Checks the services are in the release
+	if err := selectServices(release, services); err != nil {
+		return err
+	}
+
+	if request == nil {
+		request = &models.HaltRequest{}
+	}
+	request.Services = release.OnlyServices
+
+	deployerARN := identity.StateMachineARN(region, accountID)
 
-	return halt(&aws.ClientsStr{}, release, deployerARN)
+	if len(release.Targets) > 0 {
+		return haltTargets(&aws.ClientsStr{}, release, request, deployerARN, output)
+	}
+
+	return halt(&aws.ClientsStr{}, release, request, deployerARN, output)
 }
 
-func halt(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+// haltTargets halts every target with a running execution
+func haltTargets(awsc aws.Clients, release *models.Release, request *models.HaltRequest, deployerARN *string, output string) error {
+	releases, err := release.TargetReleases()
+	if err != nil {
+		return err
+	}
+
+	running := []*models.Release{}
+	for _, tr := range releases {
+		releaseID, err := runningReleaseID(awsc.SFNClient(nil, nil, nil), deployerARN, tr)
+		if err != nil {
+			return err
+		}
+
+		// Each target has its own release ID so only one can match
+		if releaseID != nil && (request.ReleaseID == nil || *request.ReleaseID == *releaseID) {
+			running = append(running, tr)
+		}
+	}
+
+	if len(running) == 0 && request.ReleaseID != nil {
+		return fmt.Errorf("Cannot find current execution of release %v in any target of the release", *request.ReleaseID)
+	}
+
+	if len(running) == 0 {
+		return fmt.Errorf("Cannot find current execution of any target of the release")
+	}
+
+	for _, tr := range running {
+		if err := halt(awsc, tr, request, deployerARN, output); err != nil {
+			return fmt.Errorf("%v: %v", *tr.Target, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func halt(awsc aws.Clients, release *models.Release, request *models.HaltRequest, deployerARN *string, output string) error {
 	exec, err := execution.FindExecution(awsc.SFNClient(nil, nil, nil), deployerARN, executionPrefix(release))
 	if err != nil {
 		return err
@@ -32,11 +83,38 @@ func halt(awsc aws.Clients, release *models.Release, deployerARN *string) error
 		return fmt.Errorf("Cannot find current execution of release with prefix %q", executionPrefix(release))
 	}
 
-	if err := release.Halt(awsc.S3Client(nil, nil, nil)); err != nil {
+	// A mistyped release ID would halt nothing, and replace any halt already written
+	if request != nil && request.ReleaseID != nil {
+		releaseID, err := runningReleaseID(awsc.SFNClient(nil, nil, nil), deployerARN, release)
+		if err != nil {
+			return err
+		}
+
+		if releaseID == nil || *releaseID != *request.ReleaseID {
+			return fmt.Errorf("Release %v is not the running release %v", *request.ReleaseID, to.Strs(releaseID))
+		}
+	}
+
+	if err := release.Halt(awsc.S3Client(nil, nil, nil), request); err != nil {
+		return err
+	}
+
+	p := newProgress(output)
+	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, p.waiter)
+
+	// A release that stopped because of the halt is what was asked for
+	if err := p.finish(); ExitCode(err) != ExitHalt {
 		return err
 	}
 
-	exec.WaitForExecution(awsc.SFNClient(nil, nil, nil), 1, waiter)
-	fmt.Println("")
 	return nil
 }
+
+// NewHaltRequest returns the halt request for the flags, empty values are not set
+func NewHaltRequest(reason *string, haltedBy *string, releaseID *string) *models.HaltRequest {
+	return &models.HaltRequest{
+		Reason:    emptyNil(reason),
+		HaltedBy:  emptyNil(haltedBy),
+		ReleaseID: emptyNil(releaseID),
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/service/sfn"
 	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,6 +27,33 @@ func Test_Halt(t *testing.T) {
 		},
 	}
 
-	err := halt(awsc, r, to.Strp("deployerARN"))
+	err := halt(awsc, r, nil, to.Strp("deployerARN"), OutputText)
 	assert.NoError(t, err)
 }
+
+func Test_Halt_ReleaseID(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+	r.SetDefaultBucket(models.DefaultIdentity(), to.Strp("accountid"))
+
+	awsc.SFN.ListExecutionsResp = &sfn.ListExecutionsOutput{
+		Executions: []*sfn.ExecutionListItem{
+			&sfn.ExecutionListItem{
+				Name:         executionName(r),
+				ExecutionArn: to.Strp("arn"),
+				StartDate:    to.Timep(time.Now()),
+			},
+		},
+	}
+	awsc.SFN.DescribeExecutionResp = &sfn.DescribeExecutionOutput{
+		Input: to.Strp(`{"release_id": "running"}`),
+	}
+
+	// The halt file is not written for another release
+	err := halt(awsc, r, &models.HaltRequest{ReleaseID: to.Strp("runing")}, to.Strp("deployerARN"), OutputText)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not the running release running")
+	assert.Nil(t, awsc.S3.GetObjectResp[*r.HaltPath()])
+}
@@ -0,0 +1,32 @@
+package client
+
+import (
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+)
+
+// Identity returns the deployer identity, flags override the identity file
+// which overrides the environment, anything unset is the default deployer
+func Identity(file *string, name *string, bucket *string) (*models.Identity, error) {
+	identity := models.IdentityFromEnv()
+
+	if file != nil && *file != "" {
+		fromFile, err := models.LoadIdentity(*file, identity)
+		if err != nil {
+			return nil, err
+		}
+		identity = fromFile
+	}
+
+	flags := &models.Identity{Name: emptyNil(name), Bucket: emptyNil(bucket)}
+	identity = flags.Merge(identity)
+
+	identity.SetDefaults()
+	return identity, nil
+}
+
+func emptyNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
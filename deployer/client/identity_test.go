@@ -0,0 +1,39 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Identity(t *testing.T) {
+	identity, err := Identity(nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DefaultDeployerName, *identity.Name)
+	assert.Nil(t, identity.Bucket)
+
+	os.Setenv(models.DeployerNameEnv, "env-deployer")
+	defer os.Unsetenv(models.DeployerNameEnv)
+
+	file, err := ioutil.TempFile("", "identity")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString(`{"bucket": "file-bucket"}`)
+	file.Close()
+
+	identity, err = Identity(to.Strp(file.Name()), to.Strp(""), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "env-deployer", *identity.Name)
+	assert.Equal(t, "env-deployer-assumed", *identity.AssumedRole)
+	assert.Equal(t, "file-bucket", *identity.Bucket)
+
+	// Flags override the file and environment
+	identity, err = Identity(to.Strp(file.Name()), to.Strp("flag-deployer"), to.Strp("flag-bucket"))
+	assert.NoError(t, err)
+	assert.Equal(t, "flag-deployer", *identity.Name)
+	assert.Equal(t, "flag-bucket", *identity.Bucket)
+}
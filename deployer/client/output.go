@@ -0,0 +1,437 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/execution"
+)
+
+// Output formats the client can print progress in
+const (
+	OutputText  = "text"  // spinner and coloured dots for a terminal
+	OutputPlain = "plain" // one line per change, for CI logs
+	OutputJSON  = "json"  // one JSON event per poll, for machines
+)
+
+// Exit codes returned by the client so pipelines can react to failures
+const (
+	ExitSuccess      = 0
+	ExitError        = 1 // Client error, e.g. could not read release
+	ExitBadRelease   = 2
+	ExitLockExists   = 3
+	ExitHalt         = 4
+	ExitTimeout      = 5
+	ExitFailureClean = 6
+	ExitFailureDirty = 7
+)
+
+// ReleaseFailedError is returned when an execution does not succeed
+type ReleaseFailedError struct {
+	Code   int
+	Status string
+	State  string
+	Err    *models.ReleaseError
+}
+
+func (e *ReleaseFailedError) Error() string {
+	msg := fmt.Sprintf("Release %v in state %v", e.Status, e.State)
+	if e.Err != nil && e.Err.Error != nil {
+		msg = fmt.Sprintf("%v with %v", msg, *e.Err.Error)
+		if e.Err.Cause != nil {
+			msg = fmt.Sprintf("%v(%v)", msg, *e.Err.Cause)
+		}
+	}
+	return msg
+}
+
+// ExitCode returns the exit code the client should exit with for an error
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	if rf, ok := err.(*ReleaseFailedError); ok {
+		return rf.Code
+	}
+
+	return ExitError
+}
+
+// ValidOutput returns an error if the output format is unknown
+func ValidOutput(output string) error {
+	switch output {
+	case OutputText, OutputPlain, OutputJSON:
+		return nil
+	}
+	return fmt.Errorf("Unknown output %q must be one of %v, %v, %v", output, OutputText, OutputPlain, OutputJSON)
+}
+
+// event is the JSON line written for each poll
+type event struct {
+	Target   string                          `json:"target,omitempty"`
+	Status   string                          `json:"status"`
+	State    string                          `json:"state"`
+	Image    string                          `json:"ami,omitempty"`
+	Paused   bool                            `json:"paused,omitempty"`
+	Retries  int64                           `json:"aws_retries,omitempty"`
+	Services map[string]*models.HealthReport `json:"services,omitempty"`
+	Error    *models.ReleaseError            `json:"error,omitempty"`
+	Done     bool                            `json:"done,omitempty"`
+	ExitCode *int                            `json:"exit_code,omitempty"`
+
+	// Diagnostics are only included in the last event of a failed release
+	Diagnostics map[string]*models.Diagnostics `json:"diagnostics,omitempty"`
+}
+
+// progress follows an execution, printing it in the chosen output
+// and remembering enough to work out how it ended
+type progress struct {
+	output string
+	out    io.Writer
+	target string // <account_id>/<region> when deploying targets
+
+	status    string
+	state     string
+	lastState string
+	release   *models.Release
+	lastLine  string
+}
+
+func newProgress(output string) *progress {
+	return &progress{output: output, out: os.Stdout}
+}
+
+// waiter is called by WaitForExecution on every poll
+func (p *progress) waiter(ed *execution.ExecutionDetails, sd *execution.StateDetails, err error) error {
+	if err != nil {
+		return fmt.Errorf("Unexpected Error %v", err.Error())
+	}
+
+	if err := p.update(ed.Status, sd); err != nil {
+		return err
+	}
+
+	switch p.output {
+	case OutputJSON:
+		return p.printJSON(false)
+	case OutputPlain:
+		p.printPlain()
+	default:
+		spinnerCounter++
+		ws, err := waiterStr(ed.Status, sd)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "\r%v%v               ", p.prefix(), ws)
+	}
+
+	return nil
+}
+
+func (p *progress) update(status *string, sd *execution.StateDetails) error {
+	if status != nil {
+		p.status = *status
+	}
+
+	p.state = stateName(sd)
+	if sd.LastStateName != nil {
+		p.lastState = *sd.LastStateName
+	}
+
+	release, err := releaseFromOutput(sd)
+	if err != nil {
+		return err
+	}
+
+	// Checks it has correctly unmarshalled
+	if release.ProjectName != nil {
+		p.release = release
+	}
+
+	return nil
+}
+
+// finish prints the final line and returns an error if the release failed
+func (p *progress) finish() error {
+	err := p.result()
+
+	switch p.output {
+	case OutputJSON:
+		if jerr := p.printJSON(true); jerr != nil {
+			return jerr
+		}
+	case OutputPlain:
+		fmt.Fprintln(p.out, "")
+		p.printDiagnostics(err)
+	default:
+		fmt.Fprintln(p.out, "")
+		if p.release != nil && resolvedImage(p.release) != "" {
+			fmt.Fprintf(p.out, "%vAMI: %v\n", p.prefix(), resolvedImage(p.release))
+		}
+		p.printDiagnostics(err)
+	}
+
+	return err
+}
+
+// printDiagnostics prints why the services were not healthy if the release failed
+func (p *progress) printDiagnostics(err error) {
+	if err == nil || p.release == nil {
+		return
+	}
+
+	for _, line := range diagnosticsLines(p.release) {
+		fmt.Fprintf(p.out, "%v%v\n", p.prefix(), line)
+	}
+}
+
+func diagnosticsLines(release *models.Release) []string {
+	lines := []string{}
+	for _, name := range serviceNames(release) {
+		diag := release.Services[name].Diagnostics
+		if diag == nil {
+			continue
+		}
+
+		for _, activity := range diag.FailedActivities {
+			lines = append(lines, fmt.Sprintf("%v: activity %v", name, strOrEmpty(activity)))
+		}
+
+		for _, id := range sortedInstanceIDs(diag.Instances) {
+			detail := diag.Instances[id]
+			line := fmt.Sprintf("%v: %v lifecycle=%v health=%v", name, id, strOrEmpty(detail.LifecycleState), strOrEmpty(detail.HealthStatus))
+
+			for _, target := range sortedTargetNames(detail.Targets) {
+				td := detail.Targets[target]
+				line = fmt.Sprintf("%v %v=%v", line, target, strOrEmpty(td.State))
+				if td.ReasonCode != nil || td.Description != nil {
+					line = fmt.Sprintf("%v(%v)", line, strings.Trim(fmt.Sprintf("%v: %v", strOrEmpty(td.ReasonCode), strOrEmpty(td.Description)), ": "))
+				}
+			}
+			lines = append(lines, line)
+
+			if detail.ConsoleOutput != nil {
+				lines = append(lines, fmt.Sprintf("%v: %v console output s3://%v/%v", name, id, strOrEmpty(release.Bucket), *detail.ConsoleOutput))
+			}
+		}
+	}
+	return lines
+}
+
+// result works out how the execution ended
+func (p *progress) result() error {
+	if p.status == "SUCCEEDED" {
+		return nil
+	}
+
+	var relErr *models.ReleaseError
+	if p.release != nil {
+		relErr = p.release.Error
+	}
+
+	return &ReleaseFailedError{
+		Code:   p.exitCode(relErr),
+		Status: p.status,
+		State:  p.state,
+		Err:    relErr,
+	}
+}
+
+func (p *progress) exitCode(relErr *models.ReleaseError) int {
+	// Stopping an execution leaves resources behind
+	if p.lastState == "FailureDirty" || p.status == "ABORTED" {
+		return ExitFailureDirty
+	}
+
+	if relErr == nil || relErr.Error == nil {
+		return ExitFailureClean
+	}
+
+	switch *relErr.Error {
+	case "BadReleaseError", "UnmarshalError":
+		return ExitBadRelease
+	case "LockExistsError":
+		return ExitLockExists
+	case "HaltError":
+		return ExitHalt
+	case "TimeoutError":
+		return ExitTimeout
+	}
+
+	return ExitFailureClean
+}
+
+// prefix is the target of the progress so the lines of targets can be told apart
+func (p *progress) prefix() string {
+	if p.target == "" {
+		return ""
+	}
+	return fmt.Sprintf("%v: ", p.target)
+}
+
+func (p *progress) plainLine() string {
+	line := fmt.Sprintf("%v%v %v", p.prefix(), p.status, p.state)
+
+	if p.release == nil {
+		return line
+	}
+
+	if ami := resolvedImage(p.release); ami != "" {
+		line = fmt.Sprintf("%v ami=%v", line, ami)
+	}
+
+	if p.release.Paused != nil && *p.release.Paused {
+		line = fmt.Sprintf("%v paused", line)
+	}
+
+	if p.release.AwsRetries != nil && *p.release.AwsRetries > 0 {
+		line = fmt.Sprintf("%v aws_retries=%v", line, *p.release.AwsRetries)
+	}
+
+	if p.release.Error != nil {
+		return fmt.Sprintf("%v error=%v cause=%q", line, strOrEmpty(p.release.Error.Error), strOrEmpty(p.release.Error.Cause))
+	}
+
+	for _, name := range serviceNames(p.release) {
+		hr := p.release.Services[name].HealthReport
+		if hr == nil {
+			continue
+		}
+		pending := ""
+		if hr.PendingHook != nil {
+			pending = fmt.Sprintf(",pending_hook=%v", *hr.PendingHook)
+		}
+		line = fmt.Sprintf("%v %v=%v/%v(launching=%v,terminating=%v%v)", line, name,
+			intOrZero(hr.Healthy), intOrZero(hr.TargetHealthy), intOrZero(hr.Launching), intOrZero(hr.Terminating), pending)
+	}
+
+	return line
+}
+
+// printPlain only prints when something changed to keep logs short
+func (p *progress) printPlain() {
+	line := p.plainLine()
+	if line == p.lastLine {
+		return
+	}
+	p.lastLine = line
+	fmt.Fprintln(p.out, line)
+}
+
+func (p *progress) printJSON(done bool) error {
+	ev := event{
+		Target: p.target,
+		Status: p.status,
+		State:  p.state,
+		Done:   done,
+	}
+
+	if p.release != nil {
+		ev.Image = resolvedImage(p.release)
+		ev.Paused = p.release.Paused != nil && *p.release.Paused
+		if p.release.AwsRetries != nil {
+			ev.Retries = *p.release.AwsRetries
+		}
+		ev.Error = p.release.Error
+		ev.Services = map[string]*models.HealthReport{}
+		for name, service := range p.release.Services {
+			if service != nil && service.HealthReport != nil {
+				ev.Services[name] = service.HealthReport
+			}
+		}
+	}
+
+	if done {
+		result := p.result()
+		code := ExitCode(result)
+		ev.ExitCode = &code
+
+		if result != nil && p.release != nil {
+			ev.Diagnostics = map[string]*models.Diagnostics{}
+			for name, service := range p.release.Services {
+				if service != nil && service.Diagnostics != nil {
+					ev.Diagnostics[name] = service.Diagnostics
+				}
+			}
+		}
+	}
+
+	raw, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(p.out, string(raw))
+	return nil
+}
+
+func releaseFromOutput(sd *execution.StateDetails) (*models.Release, error) {
+	var release models.Release
+	if sd.LastOutput != nil {
+		if err := json.Unmarshal([]byte(*sd.LastOutput), &release); err != nil {
+			return nil, err
+		}
+	}
+	return &release, nil
+}
+
+// resolvedImage returns the AMI ID the deployer found for the release's ami
+func resolvedImage(release *models.Release) string {
+	for _, name := range serviceNames(release) {
+		res := release.Services[name].Resources
+		if res != nil && res.Image != nil {
+			return *res.Image
+		}
+	}
+	return ""
+}
+
+func serviceNames(release *models.Release) []string {
+	names := []string{}
+	for name, service := range release.Services {
+		if service != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedInstanceIDs(details aws.InstanceDetails) []string {
+	ids := []string{}
+	for id := range details {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedTargetNames(targets map[string]*aws.TargetDetail) []string {
+	names := []string{}
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func strOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func testProgress(output string) (*progress, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	p := newProgress(output)
+	p.out = buf
+	return p, buf
+}
+
+func releaseWithError(t *testing.T, errName string, cause string) *models.Release {
+	r := minimalRelease(t)
+	r.Error = &models.ReleaseError{Error: to.Strp(errName), Cause: to.Strp(cause)}
+	return r
+}
+
+func Test_ValidOutput(t *testing.T) {
+	assert.NoError(t, ValidOutput("text"))
+	assert.NoError(t, ValidOutput("plain"))
+	assert.NoError(t, ValidOutput("json"))
+	assert.Error(t, ValidOutput("yaml"))
+}
+
+func Test_ExitCode(t *testing.T) {
+	assert.Equal(t, ExitSuccess, ExitCode(nil))
+	assert.Equal(t, ExitError, ExitCode(fmt.Errorf("client error")))
+	assert.Equal(t, ExitLockExists, ExitCode(&ReleaseFailedError{Code: ExitLockExists}))
+}
+
+func Test_progress_ExitCodes(t *testing.T) {
+	cases := []struct {
+		status string
+		state  string
+		err    string
+		cause  string
+		code   int
+	}{
+		{"FAILED", "FailureClean", "BadReleaseError", "bad", ExitBadRelease},
+		{"FAILED", "FailureClean", "UnmarshalError", "bad", ExitBadRelease},
+		{"FAILED", "FailureClean", "LockExistsError", "lock", ExitLockExists},
+		{"FAILED", "FailureClean", "HaltError", "Halt Detected", ExitHalt},
+		{"FAILED", "FailureClean", "TimeoutError", "Timeout: Halting Service", ExitTimeout},
+		{"FAILED", "FailureClean", "HaltError", "Halted by alice: Timeout in checkout", ExitHalt},
+		{"FAILED", "FailureClean", "DeployError", "deploy", ExitFailureClean},
+		{"FAILED", "FailureDirty", "CleanUpError", "clean", ExitFailureDirty},
+		{"ABORTED", "CheckHealthy", "", "", ExitFailureDirty},
+	}
+
+	for _, c := range cases {
+		p, _ := testProgress(OutputPlain)
+		r := minimalRelease(t)
+		if c.err != "" {
+			r = releaseWithError(t, c.err, c.cause)
+		}
+
+		sd := createStateDetails(r, "")
+		sd.LastTaskName = nil
+		sd.LastStateName = to.Strp(c.state)
+
+		assert.NoError(t, p.waiter(&execution.ExecutionDetails{Status: to.Strp(c.status)}, sd, nil))
+		assert.Equal(t, c.code, ExitCode(p.finish()), c.err)
+	}
+}
+
+func Test_progress_Success(t *testing.T) {
+	p, _ := testProgress(OutputPlain)
+	sd := createStateDetails(minimalRelease(t), "Success")
+	assert.NoError(t, p.waiter(&execution.ExecutionDetails{Status: to.Strp("SUCCEEDED")}, sd, nil))
+	assert.NoError(t, p.finish())
+}
+
+func Test_progress_Plain(t *testing.T) {
+	p, buf := testProgress(OutputPlain)
+	r := minimalRelease(t)
+	r.Services["web"].HealthReport = &models.HealthReport{
+		TargetHealthy:  to.Intp(3),
+		TargetLaunched: to.Intp(5),
+		Healthy:        to.Intp(1),
+		Launching:      to.Intp(5),
+		Terminating:    to.Intp(0),
+	}
+
+	ed := &execution.ExecutionDetails{Status: to.Strp("RUNNING")}
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "CheckHealthy"), nil))
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "CheckHealthy"), nil))
+
+	// Only printed once as nothing changed
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 1, len(lines))
+	assert.Equal(t, "RUNNING CheckHealthy web=1/3(launching=5,terminating=0)", lines[0])
+	assert.NotContains(t, buf.String(), "\x1b")
+
+	r.AwsRetries = to.Int64p(3)
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "CheckHealthy"), nil))
+	assert.Contains(t, buf.String(), "RUNNING CheckHealthy aws_retries=3 web=1/3")
+}
+
+func Test_progress_JSON(t *testing.T) {
+	p, buf := testProgress(OutputJSON)
+	r := releaseWithError(t, "HaltError", "Halt Detected")
+	r.AwsRetries = to.Int64p(2)
+
+	ed := &execution.ExecutionDetails{Status: to.Strp("FAILED")}
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "CleanUpFailure"), nil))
+	assert.Error(t, p.finish())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+
+	var ev event
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &ev))
+	assert.Equal(t, "FAILED", ev.Status)
+	assert.Equal(t, "CleanUpFailure", ev.State)
+	assert.Equal(t, "HaltError", *ev.Error.Error)
+	assert.Equal(t, int64(2), ev.Retries)
+	assert.Nil(t, ev.ExitCode)
+
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &ev))
+	assert.True(t, ev.Done)
+	assert.Equal(t, ExitHalt, *ev.ExitCode)
+}
+
+func Test_progress_Diagnostics(t *testing.T) {
+	r := releaseWithError(t, "TimeoutError", "Timeout: Halting Service")
+	r.Services["web"].Diagnostics = &models.Diagnostics{
+		FailedActivities: []*string{to.Strp("Failed: Launching a new EC2 instance")},
+		Instances: aws.InstanceDetails{
+			"i-1": &aws.InstanceDetail{
+				LifecycleState: to.Strp("InService"),
+				HealthStatus:   to.Strp("Healthy"),
+				Targets: map[string]*aws.TargetDetail{
+					"web-elb": &aws.TargetDetail{State: to.Strp("OutOfService"), ReasonCode: to.Strp("Instance"), Description: to.Strp("failed health checks")},
+				},
+				ConsoleOutput: to.Strp("path/console/web/i-1"),
+			},
+		},
+	}
+
+	p, buf := testProgress(OutputPlain)
+	ed := &execution.ExecutionDetails{Status: to.Strp("FAILED")}
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "CleanUpFailure"), nil))
+	assert.Error(t, p.finish())
+
+	assert.Contains(t, buf.String(), "web: activity Failed: Launching a new EC2 instance\n")
+	assert.Contains(t, buf.String(), "web: i-1 lifecycle=InService health=Healthy web-elb=OutOfService(Instance: failed health checks)\n")
+	assert.Contains(t, buf.String(), "web: i-1 console output s3://")
+	assert.Contains(t, buf.String(), "/path/console/web/i-1\n")
+
+	// JSON only has diagnostics in the last event
+	p, buf = testProgress(OutputJSON)
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "CleanUpFailure"), nil))
+	assert.Error(t, p.finish())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var ev event
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &ev))
+	assert.Nil(t, ev.Diagnostics)
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &ev))
+	assert.Equal(t, "InService", *ev.Diagnostics["web"].Instances["i-1"].LifecycleState)
+
+	// Nothing is printed for a successful release
+	p, buf = testProgress(OutputPlain)
+	assert.NoError(t, p.waiter(&execution.ExecutionDetails{Status: to.Strp("SUCCEEDED")}, createStateDetails(r, "Success"), nil))
+	assert.NoError(t, p.finish())
+	assert.NotContains(t, buf.String(), "activity")
+}
+
+func Test_progress_ResolvedImage(t *testing.T) {
+	r := minimalRelease(t)
+	r.Services["web"].Resources = &models.ServiceResourceNames{Image: to.Strp("ami-654321")}
+	ed := &execution.ExecutionDetails{Status: to.Strp("RUNNING")}
+
+	p, buf := testProgress(OutputPlain)
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "Deploy"), nil))
+	assert.Equal(t, "RUNNING Deploy ami=ami-654321", strings.TrimSpace(buf.String()))
+
+	p, buf = testProgress(OutputJSON)
+	assert.NoError(t, p.waiter(ed, createStateDetails(r, "Deploy"), nil))
+
+	var ev event
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &ev))
+	assert.Equal(t, "ami-654321", ev.Image)
+
+	p, buf = testProgress(OutputText)
+	assert.NoError(t, p.waiter(&execution.ExecutionDetails{Status: to.Strp("SUCCEEDED")}, createStateDetails(r, "Success"), nil))
+	assert.NoError(t, p.finish())
+	assert.Contains(t, buf.String(), "AMI: ami-654321")
+}
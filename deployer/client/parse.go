@@ -0,0 +1,303 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Release files can be JSON or YAML (JSON is YAML).
+// While parsing the location of every path is recorded so errors
+// can point to the file, line and column of the field that caused them.
+
+// location of a field in a release file
+type location struct {
+	file   string
+	line   int
+	column int
+}
+
+func (l *location) String() string {
+	return fmt.Sprintf("%v:%v:%v", l.file, l.line, l.column)
+}
+
+// locations maps a JSON path e.g. services.web.autoscaling.spread to where it is defined
+type locations map[string]*location
+
+// parseRelease parses a YAML or JSON release
+func parseRelease(file string, raw []byte) (map[string]interface{}, locations, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%v: %v", file, err.Error())
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil, fmt.Errorf("%v: release is empty", file)
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("%v:%v:%v release must be an object", file, root.Line, root.Column)
+	}
+
+	var release map[string]interface{}
+	if err := root.Decode(&release); err != nil {
+		return nil, nil, fmt.Errorf("%v: %v", file, err.Error())
+	}
+
+	locs := locations{}
+	locs.index(file, root, "")
+
+	return release, locs, nil
+}
+
+func (locs locations) index(file string, node *yaml.Node, path string) {
+	locs[path] = &location{file, node.Line, node.Column}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := joinPath(path, key.Value)
+			locs.index(file, value, keyPath)
+			// Point at the key not the value
+			locs[keyPath] = &location{file, key.Line, key.Column}
+		}
+	case yaml.SequenceNode:
+		for i, el := range node.Content {
+			locs.index(file, el, fmt.Sprintf("%v[%v]", path, i))
+		}
+	}
+}
+
+// merge adds the overlay locations, they replace the base locations
+func (locs locations) merge(overlay locations) {
+	for path, loc := range overlay {
+		if path == "" {
+			continue // keep the base file as root
+		}
+		locs[path] = loc
+	}
+}
+
+// find returns the location of path or its closest defined parent
+func (locs locations) find(path string) *location {
+	for {
+		if loc, ok := locs[path]; ok {
+			return loc
+		}
+
+		if path == "" {
+			return nil
+		}
+
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			path = ""
+		} else {
+			path = path[:i]
+		}
+	}
+}
+
+// locate returns err with the location of the field that caused it
+func (locs locations) locate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	fe, ok := err.(*models.FieldError)
+	if !ok {
+		return err
+	}
+
+	loc := locs.find(fe.Path)
+	if loc == nil {
+		return err
+	}
+
+	return fmt.Errorf("%v %v", loc, fe.Error())
+}
+
+func joinPath(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return fmt.Sprintf("%v.%v", prefix, key)
+}
+
+//////////
+// Strict Fields
+//////////
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// checkFields returns a FieldError if the value has a key or type that t does not support.
+// This matches Release.UnmarshalJSON (which disallows unknown fields) but knows the path.
+func checkFields(value interface{}, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	if t == timeType {
+		switch value.(type) {
+		case string, time.Time:
+			return nil
+		}
+		return typeError(path, "time", value)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := value.(string); ok && models.AcceptsString(t) {
+			return nil
+		}
+
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(path, "object", value)
+		}
+
+		for _, key := range sortedKeys(m) {
+			ft, ok := jsonField(t, key)
+			if !ok {
+				return &models.FieldError{Path: joinPath(path, key), Err: fmt.Sprintf("unknown field %q", key)}
+			}
+
+			if err := checkFields(m[key], ft, joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(path, "object", value)
+		}
+
+		for _, key := range sortedKeys(m) {
+			if err := checkFields(m[key], t.Elem(), joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		l, ok := value.([]interface{})
+		if !ok {
+			return typeError(path, "list", value)
+		}
+
+		for i, el := range l {
+			if err := checkFields(el, t.Elem(), fmt.Sprintf("%v[%v]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			return typeError(path, "string", value)
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			return typeError(path, "boolean", value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := value.(type) {
+		case int:
+			return nil
+		case float64:
+			if v == float64(int64(v)) {
+				return nil
+			}
+		}
+		return typeError(path, "integer", value)
+	case reflect.Float32, reflect.Float64:
+		switch value.(type) {
+		case int, float64:
+			return nil
+		}
+		return typeError(path, "number", value)
+	}
+
+	return nil
+}
+
+// jsonField returns the type of the field with the JSON key, like encoding/json
+// an exact match is preferred and then a case insensitive one.
+func jsonField(t reflect.Type, key string) (reflect.Type, bool) {
+	var fold reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		if name == key {
+			return f.Type, true
+		}
+
+		if fold == nil && strings.EqualFold(name, key) {
+			fold = f.Type
+		}
+	}
+
+	return fold, fold != nil
+}
+
+func typeError(path string, expected string, value interface{}) error {
+	return &models.FieldError{Path: path, Err: fmt.Sprintf("expected %v got %v", expected, typeName(value))}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "list"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, float64:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkReleaseFields checks the release with the same exceptions as Release.UnmarshalJSON
+func checkReleaseFields(release map[string]interface{}) error {
+	fields := map[string]interface{}{}
+	for key, value := range release {
+		if strings.EqualFold(key, "Task") {
+			continue
+		}
+		fields[key] = value
+	}
+
+	return checkFields(fields, reflect.TypeOf(models.Release{}), "")
+}
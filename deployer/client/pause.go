@@ -0,0 +1,137 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Pause stops a running release leaving WaitForHealthy until it is resumed
+func Pause(fileOrJSON *string, config *string, identity *models.Identity) error {
+	region, accountID := to.RegionAccount()
+	release, err := releaseFromFileOrJSON(fileOrJSON, config, region, accountID, identity)
+	if err != nil {
+		return err
+	}
+
+	deployerARN := identity.StateMachineARN(region, accountID)
+
+	if len(release.Targets) == 0 {
+		return pause(&aws.ClientsStr{}, release, deployerARN)
+	}
+
+	releases, err := release.TargetReleases()
+	if err != nil {
+		return err
+	}
+
+	paused := 0
+	for _, tr := range releases {
+		err := pause(&aws.ClientsStr{}, tr, deployerARN)
+		if _, ok := err.(*noExecutionError); ok {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%v: %v", *tr.Target, err.Error())
+		}
+		paused++
+	}
+
+	if paused == 0 {
+		return fmt.Errorf("Cannot find current execution of any target of the release")
+	}
+
+	return nil
+}
+
+// Resume lets a paused release continue, for targets every paused target is resumed
+func Resume(fileOrJSON *string, config *string, identity *models.Identity) error {
+	region, accountID := to.RegionAccount()
+	release, err := releaseFromFileOrJSON(fileOrJSON, config, region, accountID, identity)
+	if err != nil {
+		return err
+	}
+
+	deployerARN := identity.StateMachineARN(region, accountID)
+
+	if len(release.Targets) == 0 {
+		return resume(&aws.ClientsStr{}, release, deployerARN)
+	}
+
+	releases, err := release.TargetReleases()
+	if err != nil {
+		return err
+	}
+
+	resumed := 0
+	for _, tr := range releases {
+		err := resume(&aws.ClientsStr{}, tr, deployerARN)
+		if _, ok := err.(*noExecutionError); ok {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%v: %v", *tr.Target, err.Error())
+		}
+		resumed++
+	}
+
+	if resumed == 0 {
+		return fmt.Errorf("Cannot find current execution of any target of the release")
+	}
+
+	return nil
+}
+
+// noExecutionError is returned when there is no running execution to pause or resume
+type noExecutionError struct {
+	prefix string
+}
+
+func (e *noExecutionError) Error() string {
+	return fmt.Sprintf("Cannot find current execution of release with prefix %q", e.prefix)
+}
+
+// withRunningReleaseID sets the release ID to the running execution's, the pause file is under it
+func withRunningReleaseID(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	releaseID, err := runningReleaseID(awsc.SFNClient(nil, nil, nil), deployerARN, release)
+	if err != nil {
+		return err
+	}
+
+	if releaseID == nil {
+		return &noExecutionError{executionPrefix(release)}
+	}
+
+	release.ReleaseID = releaseID
+	return nil
+}
+
+func pause(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	if err := withRunningReleaseID(awsc, release, deployerARN); err != nil {
+		return err
+	}
+
+	if err := release.Pause(awsc.S3Client(nil, nil, nil)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote pause file s3://%v/%v\n", to.Strs(release.Bucket), *release.PausePath())
+	return nil
+}
+
+func resume(awsc aws.Clients, release *models.Release, deployerARN *string) error {
+	if err := withRunningReleaseID(awsc, release, deployerARN); err != nil {
+		return err
+	}
+
+	if err := release.Resume(awsc.S3Client(nil, nil, nil)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted pause file s3://%v/%v\n", to.Strs(release.Bucket), *release.PausePath())
+	return nil
+}
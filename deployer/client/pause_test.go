@@ -0,0 +1,48 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Pause(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := minimalRelease(t)
+
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+	r.SetDefaultBucket(models.DefaultIdentity(), to.Strp("accountid"))
+
+	// No execution is not paused
+	err := pause(awsc, r, to.Strp("deployerARN"))
+	assert.IsType(t, &noExecutionError{}, err)
+	assert.IsType(t, &noExecutionError{}, resume(awsc, r, to.Strp("deployerARN")))
+
+	awsc.SFN.ListExecutionsResp = &sfn.ListExecutionsOutput{
+		Executions: []*sfn.ExecutionListItem{
+			&sfn.ExecutionListItem{
+				Name:         executionName(r),
+				ExecutionArn: to.Strp("arn"),
+				StartDate:    to.Timep(time.Now()),
+			},
+		},
+	}
+
+	awsc.SFN.DescribeExecutionResp = &sfn.DescribeExecutionOutput{
+		Input: to.Strp(`{"release_id": "running"}`),
+	}
+
+	assert.NoError(t, pause(awsc, r, to.Strp("deployerARN")))
+	assert.Equal(t, "running", *r.ReleaseID)
+
+	paused, err := r.IsPaused(awsc.S3)
+	assert.NoError(t, err)
+	assert.True(t, paused)
+
+	assert.NoError(t, resume(awsc, r, to.Strp("deployerARN")))
+}
@@ -0,0 +1,357 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/aws/aws-sdk-go/service/sfn/sfniface"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/alarms"
+	"github.com/coinbase/step-asg-deployer/aws/asg"
+	"github.com/coinbase/step-asg-deployer/aws/lc"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/execution"
+	"github.com/coinbase/step/utils/to"
+)
+
+// maxSucceededExecutions is how many of the latest succeeded executions are read for the releases to keep
+const maxSucceededExecutions = 10
+
+// Reconcile recovers a project config left in FailureDirty, for each service it keeps the ASG of the
+// latest succeeded release, or of keepRelease, and after confirmation tears down everything else
+func Reconcile(fileOrJSON *string, config *string, identity *models.Identity, keepRelease *string, yes bool) error {
+	region, accountID := to.RegionAccount()
+	release, err := releaseFromFileOrJSON(fileOrJSON, config, region, accountID, identity)
+	if err != nil {
+		return err
+	}
+
+	// Reconcile the target in the account and region of the credentials
+	if len(release.Targets) > 0 {
+		release, err = currentTarget(release, region, accountID)
+		if err != nil {
+			return err
+		}
+	}
+
+	deployerARN := identity.StateMachineARN(region, accountID)
+
+	confirm := func() bool {
+		if yes {
+			fmt.Println("yes")
+			return true
+		}
+		return confirmYes(os.Stdin)
+	}
+	return reconcile(&aws.ClientsStr{}, release, deployerARN, emptyNil(keepRelease), os.Stdout, confirm)
+}
+
+// reconcilePlan is what reconcile found and will tear down
+type reconcilePlan struct {
+	asgs     []*asg.ASG
+	live     []*asg.ASG        // Every ASG, the launch configurations and alarms they use are never torn down
+	healthy  map[string]bool   // ASG names healthy in their ASG, ELBs and Target Groups
+	keep     map[string]bool   // ASG names to keep
+	kept     map[string]string // Services with all their ASGs kept, and why
+	configs  []*string         // Launch configurations without an ASG
+	alarms   []*string         // Alarms without an ASG
+	teardown []*asg.ASG
+}
+
+func reconcile(awsc aws.Clients, release *models.Release, deployerARN *string, keepRelease *string, out io.Writer, confirm func() bool) error {
+	sfnc := awsc.SFNClient(nil, nil, nil)
+	exec, err := execution.FindExecution(sfnc, deployerARN, executionPrefix(release))
+	if err != nil {
+		return err
+	}
+
+	if exec != nil {
+		return fmt.Errorf("Release is still running with prefix %q, halt it before reconciling", executionPrefix(release))
+	}
+
+	keepIDs := []string{}
+	if keepRelease != nil {
+		keepIDs = append(keepIDs, *keepRelease)
+	} else {
+		keepIDs, err = succeededReleaseIDs(sfnc, deployerARN, executionPrefix(release))
+		if err != nil {
+			return err
+		}
+
+		if len(keepIDs) == 0 {
+			return fmt.Errorf("Cannot find a succeeded release with prefix %q, use --keep-release to choose the release to keep", executionPrefix(release))
+		}
+	}
+
+	asgc := awsc.ASGClient(nil, nil, nil)
+	cwc := awsc.CWClient(nil, nil, nil)
+
+	plan, err := newReconcilePlan(asgc, awsc.ELBClient(nil, nil, nil), awsc.ALBClient(nil, nil, nil), cwc, release, keepIDs)
+	if err != nil {
+		return err
+	}
+
+	plan.print(out)
+
+	fmt.Fprintf(out, "Tear down %v ASGs, %v launch configurations and %v alarms, and remove the lock and halt files? Type yes to continue: ",
+		len(plan.teardown), len(plan.configs), len(plan.alarms))
+
+	if !confirm() {
+		fmt.Fprintln(out, "Nothing torn down")
+		return nil
+	}
+
+	for _, group := range plan.teardown {
+		if err := group.Teardown(asgc, cwc); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range plan.configs {
+		if err := lc.Teardown(asgc, name); err != nil {
+			return err
+		}
+	}
+
+	if err := alarms.Teardown(cwc, plan.alarms); err != nil {
+		return err
+	}
+
+	if err := release.ClearLock(awsc.S3Client(nil, nil, nil)); err != nil {
+		return err
+	}
+
+	release.RemoveHalt(awsc.S3Client(nil, nil, nil))
+
+	fmt.Fprintln(out, "Reconciled")
+	return nil
+}
+
+// succeededReleaseIDs returns the IDs of the latest releases that succeeded, newest first
+func succeededReleaseIDs(sfnc sfniface.SFNAPI, deployerARN *string, prefix string) ([]string, error) {
+	execs := []*sfn.ExecutionListItem{}
+	input := &sfn.ListExecutionsInput{
+		StateMachineArn: deployerARN,
+		StatusFilter:    to.Strp(sfn.ExecutionStatusSucceeded),
+	}
+
+	for {
+		page, err := sfnc.ListExecutions(input)
+		if err != nil {
+			return nil, err
+		}
+
+		if page == nil {
+			break
+		}
+
+		for _, exec := range page.Executions {
+			if exec.Name != nil && exec.StartDate != nil && strings.HasPrefix(*exec.Name, prefix) {
+				execs = append(execs, exec)
+			}
+		}
+
+		if page.NextToken == nil {
+			break
+		}
+		input.NextToken = page.NextToken
+	}
+
+	sort.Slice(execs, func(i, j int) bool {
+		return execs[i].StartDate.After(*execs[j].StartDate)
+	})
+
+	if len(execs) > maxSucceededExecutions {
+		execs = execs[:maxSucceededExecutions]
+	}
+
+	ids := []string{}
+	for _, exec := range execs {
+		desc, err := sfnc.DescribeExecution(&sfn.DescribeExecutionInput{ExecutionArn: exec.ExecutionArn})
+		if err != nil {
+			return nil, err
+		}
+
+		var release models.Release
+		if desc.Output == nil || json.Unmarshal([]byte(*desc.Output), &release) != nil {
+			continue
+		}
+
+		if release.ReleaseID != nil && release.Success != nil && *release.Success {
+			ids = append(ids, *release.ReleaseID)
+		}
+	}
+
+	return ids, nil
+}
+
+func newReconcilePlan(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI, cwc aws.CWAPI, release *models.Release, keepIDs []string) (*reconcilePlan, error) {
+	asgs, err := asg.ForProjectConfig(asgc, release.ProjectName, release.ConfigName)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := asg.All(asgc)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &reconcilePlan{asgs: asgs, live: live, healthy: map[string]bool{}, keep: map[string]bool{}, kept: map[string]string{}}
+
+	for _, group := range asgs {
+		// An ASG whose health cannot be checked is not healthy so it is not the only ASG kept
+		healthy, err := group.HealthyInLoadBalancers(elbc, albc)
+		plan.healthy[*group.AutoScalingGroupName] = err == nil && healthy
+	}
+
+	// Keep the ASG of the latest succeeded release with the service, services can be from different releases with only_services
+	byService := map[string][]*asg.ASG{}
+	for _, group := range asgs {
+		name := to.Strs(group.ServiceName())
+		byService[name] = append(byService[name], group)
+	}
+
+	for name, groups := range byService {
+		current := keepASG(groups, keepIDs)
+		switch {
+		case current == nil:
+			plan.kept[name] = "has no ASG from a succeeded release"
+		case !plan.healthy[*current.AutoScalingGroupName]:
+			plan.kept[name] = fmt.Sprintf("ASG %v of release %v is not healthy", *current.AutoScalingGroupName, to.Strs(current.ReleaseID()))
+		default:
+			plan.keep[*current.AutoScalingGroupName] = true
+			continue
+		}
+
+		for _, group := range groups {
+			plan.keep[*group.AutoScalingGroupName] = true
+		}
+	}
+
+	for _, group := range asgs {
+		if !plan.keep[*group.AutoScalingGroupName] {
+			plan.teardown = append(plan.teardown, group)
+		}
+	}
+
+	// Launch configurations and alarms are named after the ASG they were created for
+	prefix := fmt.Sprintf("%v-%v-", *release.ProjectName, *release.ConfigName)
+
+	configs, err := lc.ForPrefix(asgc, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range configs {
+		if plan.serviceResource(release, *name) && !plan.hasASG(*name) {
+			plan.configs = append(plan.configs, name)
+		}
+	}
+
+	metricAlarms, err := alarms.ForPrefix(cwc, &prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, alarm := range metricAlarms {
+		if plan.serviceResource(release, *alarm.AlarmName) && !plan.hasASG(*alarm.AlarmName) {
+			plan.alarms = append(plan.alarms, alarm.AlarmName)
+		}
+	}
+
+	return plan, nil
+}
+
+// keepASG returns the ASG of the first release in keepIDs that has one
+func keepASG(groups []*asg.ASG, keepIDs []string) *asg.ASG {
+	for _, id := range keepIDs {
+		for _, group := range groups {
+			if to.Strs(group.ReleaseID()) == id {
+				return group
+			}
+		}
+	}
+	return nil
+}
+
+// serviceResource returns true if the name is exactly the ServiceID of a service in the release,
+// or its launch configuration or alarm, so other projects and configs with dashes are not matched
+func (plan *reconcilePlan) serviceResource(release *models.Release, name string) bool {
+	for serviceName := range release.Services {
+		if asg.IsServiceResource(name, *release.ProjectName, *release.ConfigName, serviceName) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasASG returns true if the launch configuration or alarm was created for any ASG that still exists,
+// these are deleted by Teardown with the ASG
+func (plan *reconcilePlan) hasASG(name string) bool {
+	for _, group := range plan.live {
+		if group.Owns(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (plan *reconcilePlan) print(out io.Writer) {
+	byRelease := map[string][]*asg.ASG{}
+	for _, group := range plan.asgs {
+		id := to.Strs(group.ReleaseID())
+		byRelease[id] = append(byRelease[id], group)
+	}
+
+	ids := []string{}
+	for id := range byRelease {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		fmt.Fprintf(out, "Release %v\n", id)
+		for _, group := range byRelease[id] {
+			action := "teardown"
+			if plan.keep[*group.AutoScalingGroupName] {
+				action = "keep"
+			}
+
+			health := "unhealthy"
+			if plan.healthy[*group.AutoScalingGroupName] {
+				health = "healthy"
+			}
+
+			fmt.Fprintf(out, "  %v %v %v %v\n", to.Strs(group.ServiceName()), *group.AutoScalingGroupName, health, action)
+		}
+	}
+
+	services := []string{}
+	for name := range plan.kept {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	for _, name := range services {
+		fmt.Fprintf(out, "Service %v %v, its ASGs are kept\n", name, plan.kept[name])
+	}
+
+	for _, name := range plan.configs {
+		fmt.Fprintf(out, "Launch configuration without an ASG %v teardown\n", *name)
+	}
+
+	for _, name := range plan.alarms {
+		fmt.Fprintf(out, "Alarm without an ASG %v teardown\n", *name)
+	}
+}
+
+func confirmYes(in io.Reader) bool {
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	return strings.TrimSpace(line) == "yes"
+}
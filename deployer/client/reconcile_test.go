@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// Left behind without an ASG
+var orphanedID = *to.TimeUUID("project-config-web-")
+
+func mockDirtyClients(t *testing.T) *mocks.MockClients {
+	awsc := mocks.MockAWS()
+	now := time.Now()
+
+	// web was deployed twice, release-2 is the newest healthy ASG
+	for i, releaseID := range []string{"release-1", "release-2"} {
+		name := awsc.ASG.AddPreviousRuntimeResources("project", "config", "web", releaseID)
+		group := awsc.ASG.DescribeAutoScalingGroupsPageResp[i].Resp.AutoScalingGroups[0]
+		group.DesiredCapacity = to.Int64p(1)
+		group.CreatedTime = to.Timep(now.Add(time.Duration(i) * time.Minute))
+		awsc.CW.AddAlarm(name + "-cpu_scale_up")
+	}
+
+	// worker has no healthy ASG
+	awsc.ASG.AddPreviousRuntimeResources("project", "config", "worker", "release-2")
+	worker := awsc.ASG.DescribeAutoScalingGroupsPageResp[2].Resp.AutoScalingGroups[0]
+	worker.Instances = mocks.MakeMockASGInstances(0, 1, 0)
+	worker.DesiredCapacity = to.Int64p(1)
+	worker.CreatedTime = to.Timep(now)
+
+	awsc.ASG.AddLaunchConfiguration(orphanedID)
+	awsc.CW.AddAlarm(orphanedID + "-cpu_scale_up")
+
+	// Other configs, including ones starting with this config, and hand made resources are not touched
+	awsc.ASG.AddLaunchConfiguration(*to.TimeUUID("project-other-web-"))
+	awsc.CW.AddAlarm(*to.TimeUUID("project-config-canary-web-") + "-cpu_scale_up")
+	awsc.ASG.AddLaunchConfiguration("project-config-web-manual")
+
+	return awsc
+}
+
+func Test_reconcile(t *testing.T) {
+	awsc := mockDirtyClients(t)
+	r := minimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+
+	out := &bytes.Buffer{}
+	err := reconcile(awsc, r, to.Strp("deployerARN"), to.Strp("release-2"), out, func() bool { return true })
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"project-config-web-release-1"}, to.StrSlice(awsc.ASG.DeletedASGs))
+	configs := to.StrSlice(awsc.ASG.DeletedLaunchConfigurations)
+	assert.Contains(t, configs, orphanedID)
+	assert.NotContains(t, configs, "project-config-web-manual")
+	assert.NotContains(t, configs, "project-config-web-release-2")
+
+	deletedAlarms := to.StrSlice(awsc.CW.DeletedAlarms)
+	assert.Contains(t, deletedAlarms, orphanedID+"-cpu_scale_up")
+	assert.NotContains(t, deletedAlarms, "project-config-web-release-2-cpu_scale_up")
+	for _, name := range deletedAlarms {
+		assert.NotContains(t, name, "canary")
+	}
+
+	assert.Contains(t, out.String(), "  web project-config-web-release-2 healthy keep\n")
+	assert.Contains(t, out.String(), "  web project-config-web-release-1 healthy teardown\n")
+	assert.Contains(t, out.String(), "Service worker ASG project-config-worker-release-2 of release release-2 is not healthy, its ASGs are kept\n")
+}
+
+func Test_reconcile_KeepRelease(t *testing.T) {
+	awsc := mockDirtyClients(t)
+	r := minimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+
+	// The newer release is healthy in its ASG but was halted, keep the last good release
+	out := &bytes.Buffer{}
+	err := reconcile(awsc, r, to.Strp("deployerARN"), to.Strp("release-1"), out, func() bool { return true })
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"project-config-web-release-2"}, to.StrSlice(awsc.ASG.DeletedASGs))
+	assert.Contains(t, out.String(), "Service worker has no ASG from a succeeded release, its ASGs are kept\n")
+}
+
+func Test_reconcile_UnhealthyInELB(t *testing.T) {
+	awsc := mockDirtyClients(t)
+	r := minimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+
+	awsc.ELB.AddELB("web-elb", "project", "config", "web")
+	awsc.ELB.DescribeInstanceHealthResp["web-elb"].Resp.InstanceStates[0].State = to.Strp("OutOfService")
+	awsc.ASG.DescribeAutoScalingGroupsPageResp[1].Resp.AutoScalingGroups[0].LoadBalancerNames = []*string{to.Strp("web-elb")}
+
+	out := &bytes.Buffer{}
+	err := reconcile(awsc, r, to.Strp("deployerARN"), to.Strp("release-2"), out, func() bool { return true })
+	assert.NoError(t, err)
+
+	assert.Nil(t, awsc.ASG.DeletedASGs)
+	assert.Contains(t, out.String(), "  web project-config-web-release-2 unhealthy keep\n")
+}
+
+func Test_reconcile_NoSucceededRelease(t *testing.T) {
+	awsc := mockDirtyClients(t)
+	r := minimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+
+	err := reconcile(awsc, r, to.Strp("deployerARN"), nil, &bytes.Buffer{}, func() bool { return true })
+	assert.Error(t, err)
+	assert.Nil(t, awsc.ASG.DeletedASGs)
+}
+
+func Test_reconcile_NotConfirmed(t *testing.T) {
+	awsc := mockDirtyClients(t)
+	r := minimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+
+	out := &bytes.Buffer{}
+	err := reconcile(awsc, r, to.Strp("deployerARN"), to.Strp("release-2"), out, func() bool { return false })
+	assert.NoError(t, err)
+
+	assert.Nil(t, awsc.ASG.DeletedASGs)
+	assert.Nil(t, awsc.CW.DeletedAlarms)
+	assert.Contains(t, out.String(), "Nothing torn down")
+}
+
+func Test_confirmYes(t *testing.T) {
+	assert.True(t, confirmYes(bytes.NewBufferString("yes\n")))
+	assert.False(t, confirmYes(bytes.NewBufferString("y\n")))
+	assert.False(t, confirmYes(bytes.NewBufferString("")))
+}
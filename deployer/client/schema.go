@@ -0,0 +1,37 @@
+package client
+
+import (
+	"os"
+
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Schema returns the JSON Schema of a release file
+func Schema() (string, error) {
+	schema := models.Schema()
+
+	// vars are replaced and removed by the client
+	properties := schema["properties"].(map[string]interface{})
+	properties["vars"] = map[string]interface{}{
+		"type": "object",
+		"additionalProperties": map[string]interface{}{
+			"type": []string{"string", "number", "boolean"},
+		},
+	}
+
+	return to.PrettyJSON(schema)
+}
+
+// Validate runs all the checks that do not need AWS on a release file
+func Validate(fileOrJSON *string, config *string, identity *models.Identity) error {
+	// Placeholders since they are not known without credentials
+	region := to.Strp(os.Getenv("AWS_REGION"))
+	if *region == "" {
+		region = to.Strp("us-east-1")
+	}
+	accountID := to.Strp("000000000000")
+
+	_, err := releaseFromFileOrJSON(fileOrJSON, config, region, accountID, identity)
+	return err
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Schema(t *testing.T) {
+	raw, err := Schema()
+	assert.NoError(t, err)
+
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(raw), &schema))
+	assert.Contains(t, schema["properties"], "vars")
+}
+
+func Test_Validate(t *testing.T) {
+	assert.NoError(t, Validate(to.Strp(`{
+    "project_name": "project",
+    "config_name": "config",
+    "user_data": "echo DATE",
+    "services": {
+      "web": { "instance_type": "t2.small", "security_groups": ["web-sg"] }
+    }
+  }`), nil))
+
+	// Policy type is not supported
+	err := Validate(to.Strp(`{
+    "project_name": "project",
+    "config_name": "config",
+    "user_data": "echo DATE",
+    "services": {
+      "web": {
+        "instance_type": "t2.small",
+        "security_groups": ["web-sg"],
+        "autoscaling": { "policies": [{ "type": "mem_scale_up" }] }
+      }
+    }
+  }`), nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "services.web.autoscaling.policies[0].type")
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/utils/to"
+)
+
+// targetResult is how the release for a target ended
+type targetResult struct {
+	release *models.Release
+	started bool
+	done    bool
+	err     error
+}
+
+func (r *targetResult) status() string {
+	switch {
+	case !r.started:
+		return "SKIPPED"
+	case r.err != nil:
+		return "FAILED"
+	}
+	return "SUCCEEDED"
+}
+
+// targetWarning is the JSON line written when a target could not be halted
+type targetWarning struct {
+	Target  string `json:"target"`
+	Warning string `json:"warning"`
+}
+
+// targetSummary is the JSON line written for each target when all targets are done
+type targetSummary struct {
+	Target   string `json:"target"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// lockedWriter stops the lines of targets deployed in parallel being mixed up
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// deployTargets deploys a release to each of its targets, sequentially or in parallel,
+// if a target fails and targets_on_failure is stop no more targets are started and running targets are halted
+func deployTargets(awsc aws.Clients, release *models.Release, deployerARN *string, output string, out io.Writer) error {
+	releases, err := release.TargetReleases()
+	if err != nil {
+		return err
+	}
+
+	parallel := release.TargetsParallel != nil && *release.TargetsParallel
+	if parallel && output == OutputText {
+		output = OutputPlain // A spinner for each target would overwrite each other
+	}
+
+	out = &lockedWriter{w: out}
+
+	results := []*targetResult{}
+	for _, tr := range releases {
+		// Set before any target starts so a target can be halted before it uploads its release
+		tr.ReleaseID = to.TimeUUID("release-")
+		results = append(results, &targetResult{release: tr})
+	}
+
+	var mu sync.Mutex
+	deployTarget := func(result *targetResult) {
+		p := newProgress(output)
+		p.out = out
+		p.target = *result.release.Target
+
+		err := deployWithProgress(awsc, result.release, deployerARN, p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		result.done = true
+		result.err = err
+
+		if err != nil && parallel && release.TargetsStopOnFailure() {
+			haltRunning(awsc, results, output, out)
+		}
+	}
+
+	if parallel {
+		var wg sync.WaitGroup
+		for _, result := range results {
+			result.started = true
+			wg.Add(1)
+			go func(result *targetResult) {
+				defer wg.Done()
+				deployTarget(result)
+			}(result)
+		}
+		wg.Wait()
+	} else {
+		for _, result := range results {
+			result.started = true
+			deployTarget(result)
+			if result.err != nil && release.TargetsStopOnFailure() {
+				break
+			}
+		}
+	}
+
+	return printTargets(out, output, results)
+}
+
+// currentTarget returns the release for the target in the region and account
+func currentTarget(release *models.Release, region *string, accountID *string) (*models.Release, error) {
+	releases, err := release.TargetReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tr := range releases {
+		if to.Strs(tr.AwsAccountID) == to.Strs(accountID) && to.Strs(tr.AwsRegion) == to.Strs(region) {
+			return tr, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Release has no target for account %v in region %v", to.Strs(accountID), to.Strs(region))
+}
+
+// haltRunning halts the targets that have not finished
+func haltRunning(awsc aws.Clients, results []*targetResult, output string, out io.Writer) {
+	for _, result := range results {
+		if result.done {
+			continue
+		}
+
+		// Scoped to the target's release so a late halt cannot halt the next release
+		request := &models.HaltRequest{
+			Reason:    to.Strp(fmt.Sprintf("another target of release %v failed", to.Strs(result.release.ReleaseID))),
+			ReleaseID: result.release.ReleaseID,
+		}
+
+		if err := result.release.Halt(awsc.S3Client(nil, nil, nil), request); err != nil {
+			printWarning(out, output, *result.release.Target, fmt.Sprintf("could not halt: %v", err.Error()))
+		}
+	}
+}
+
+// printWarning prints to out so JSON output stays one JSON object per line
+func printWarning(out io.Writer, output string, target string, warning string) {
+	if output == OutputJSON {
+		raw, err := json.Marshal(targetWarning{Target: target, Warning: warning})
+		if err == nil {
+			fmt.Fprintln(out, string(raw))
+		}
+		return
+	}
+
+	fmt.Fprintf(out, "%v: Warning: %v\n", target, warning)
+}
+
+// printTargets prints how each target ended and returns the first error
+func printTargets(out io.Writer, output string, results []*targetResult) error {
+	var first error
+	for _, result := range results {
+		if result.err != nil && first == nil {
+			first = result.err
+		}
+
+		if output == OutputJSON {
+			summary := targetSummary{Target: *result.release.Target, Status: result.status(), ExitCode: ExitCode(result.err)}
+			if result.err != nil {
+				summary.Error = result.err.Error()
+			}
+
+			raw, err := json.Marshal(summary)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, string(raw))
+			continue
+		}
+
+		line := fmt.Sprintf("%v: %v", *result.release.Target, result.status())
+		if result.err != nil {
+			line = fmt.Sprintf("%v %v", line, result.err.Error())
+		}
+		fmt.Fprintln(out, line)
+	}
+
+	return first
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func targetsRelease(t *testing.T) *models.Release {
+	r := minimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("accountid"))
+	r.Targets = []*models.Target{
+		&models.Target{AwsAccountID: to.Strp("accountid"), AwsRegion: to.Strp("us-east-1")},
+		&models.Target{AwsAccountID: to.Strp("accountid"), AwsRegion: to.Strp("eu-west-1")},
+	}
+	return r
+}
+
+func Test_deployTargets(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := targetsRelease(t)
+
+	var out bytes.Buffer
+	assert.NoError(t, deployTargets(awsc, r, to.Strp("deployerARN"), OutputPlain, &out))
+	assert.Contains(t, out.String(), "accountid/us-east-1: SUCCEEDED")
+	assert.Contains(t, out.String(), "accountid/eu-west-1: SUCCEEDED")
+}
+
+func Test_haltRunning(t *testing.T) {
+	awsc := mocks.MockAWS()
+	r := targetsRelease(t)
+	r.SetDefaultBucket(models.DefaultIdentity(), to.Strp("accountid"))
+
+	releases, err := r.TargetReleases()
+	assert.NoError(t, err)
+
+	// The release that has not started still has its ID so the halt is scoped to it
+	done, pending := releases[0], releases[1]
+	done.ReleaseID = to.Strp("release-done")
+	pending.ReleaseID = to.Strp("release-pending")
+
+	results := []*targetResult{
+		&targetResult{release: done, started: true, done: true},
+		&targetResult{release: pending, started: true},
+	}
+
+	var out bytes.Buffer
+	haltRunning(awsc, results, OutputJSON, &out)
+	assert.Empty(t, out.String())
+
+	assert.Nil(t, awsc.S3.GetObjectResp[*done.HaltPath()])
+	assert.NotNil(t, awsc.S3.GetObjectResp[*pending.HaltPath()])
+
+	var request models.HaltRequest
+	assert.NoError(t, s3.GetStruct(awsc.S3, pending.Bucket, pending.HaltPath(), &request))
+	assert.Equal(t, "release-pending", *request.ReleaseID)
+}
+
+func Test_printWarning(t *testing.T) {
+	var out bytes.Buffer
+	printWarning(&out, OutputJSON, "accountid/us-east-1", "could not halt: denied")
+	assert.Equal(t, `{"target":"accountid/us-east-1","warning":"could not halt: denied"}`+"\n", out.String())
+
+	out.Reset()
+	printWarning(&out, OutputPlain, "accountid/us-east-1", "could not halt: denied")
+	assert.Equal(t, "accountid/us-east-1: Warning: could not halt: denied\n", out.String())
+}
+
+func Test_executionPrefix_Target(t *testing.T) {
+	r := targetsRelease(t)
+	releases, err := r.TargetReleases()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "deploy-project-config-", executionPrefix(r))
+	assert.Equal(t, "deploy-project-config-accountid-us-east-1-", executionPrefix(releases[0]))
+}
+
+func Test_currentTarget(t *testing.T) {
+	r := targetsRelease(t)
+
+	tr, err := currentTarget(r, to.Strp("eu-west-1"), to.Strp("accountid"))
+	assert.NoError(t, err)
+	assert.Equal(t, "accountid/eu-west-1", *tr.Target)
+
+	_, err = currentTarget(r, to.Strp("ap-south-1"), to.Strp("accountid"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+)
+
+// Releases can be built from a base release file and an overlay per config
+// e.g. release.json + release.production.json (or release.yaml + release.production.yaml)
+// are merged before upload.
+// Both can define "vars" which are replaced in any string with {{vars.NAME}}.
+
+var varsRegex = regexp.MustCompile(`{{vars\.([a-zA-Z0-9_\-]+)}}`)
+
+// overlayPath returns the path of the overlay for a config
+// e.g. release.json with config production is release.production.json
+func overlayPath(basePath string, config string) string {
+	ext := filepath.Ext(basePath)
+	return fmt.Sprintf("%v.%v%v", strings.TrimSuffix(basePath, ext), config, ext)
+}
+
+// templateRelease merges the overlay into the base, reads the user data file,
+// replaces vars and returns the resulting release JSON with the location of its fields
+func templateRelease(releaseFileOrJSON *string, config *string) ([]byte, locations, error) {
+	raw, err := fileOrJSON(releaseFileOrJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isFile := raw != releaseFileOrJSON
+
+	file := "release"
+	if isFile {
+		file = *releaseFileOrJSON
+	}
+
+	release, locs, err := parseRelease(file, []byte(*raw))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config != nil && *config != "" {
+		if !isFile {
+			return nil, nil, fmt.Errorf("Config overlay requires a release file not JSON")
+		}
+
+		path := overlayPath(*releaseFileOrJSON, *config)
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		overlay, overlayLocs, err := parseRelease(path, buf)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		release = mergeMaps(release, overlay)
+		locs.merge(overlayLocs)
+	}
+
+	vars, err := popVars(release)
+	if err != nil {
+		return nil, nil, locs.locate(err)
+	}
+
+	// replace user_data_file with .userdata
+	if ud, ok := release["user_data"].(string); ok && ud == "{{USER_DATA_FILE}}" {
+		buf, err := ioutil.ReadFile(fmt.Sprintf("%v.userdata", *releaseFileOrJSON))
+		if err != nil {
+			return nil, nil, err
+		}
+		release["user_data"] = string(buf)
+	}
+
+	missing := map[string]bool{}
+	templated := replaceVars(release, vars, missing)
+
+	if len(missing) > 0 {
+		names := []string{}
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, nil, fmt.Errorf("Missing vars: %v", strings.Join(names, ", "))
+	}
+
+	if err := checkReleaseFields(release); err != nil {
+		return nil, nil, locs.locate(err)
+	}
+
+	jsonRaw, err := json.Marshal(templated)
+	return jsonRaw, locs, err
+}
+
+// mergeMaps deep merges overlay into base, values in the overlay win.
+// Lists are replaced not appended so an overlay can remove elements.
+func mergeMaps(base map[string]interface{}, overlay map[string]interface{}) map[string]interface{} {
+	for key, ov := range overlay {
+		bm, bok := base[key].(map[string]interface{})
+		om, ook := ov.(map[string]interface{})
+		if bok && ook {
+			base[key] = mergeMaps(bm, om)
+			continue
+		}
+		base[key] = ov
+	}
+	return base
+}
+
+// popVars removes and returns the vars from the release
+func popVars(release map[string]interface{}) (map[string]interface{}, error) {
+	raw, ok := release["vars"]
+	if !ok {
+		return map[string]interface{}{}, nil
+	}
+
+	delete(release, "vars")
+
+	vars, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, &models.FieldError{Path: "vars", Err: "vars must be an object"}
+	}
+
+	for name, value := range vars {
+		switch value.(type) {
+		case string, int, float64, bool:
+		default:
+			return nil, &models.FieldError{Path: fmt.Sprintf("vars.%v", name), Err: "must be a string, number or boolean"}
+		}
+	}
+
+	return vars, nil
+}
+
+// replaceVars returns value with all vars replaced, any unknown vars are added to missing.
+// A string that is only a var takes the var's type so numbers can be templated.
+func replaceVars(value interface{}, vars map[string]interface{}, missing map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, el := range v {
+			v[key] = replaceVars(el, vars, missing)
+		}
+		return v
+	case []interface{}:
+		for i, el := range v {
+			v[i] = replaceVars(el, vars, missing)
+		}
+		return v
+	case string:
+		if m := varsRegex.FindStringSubmatch(v); m != nil && m[0] == v {
+			if val, ok := vars[m[1]]; ok {
+				return val
+			}
+		}
+
+		return varsRegex.ReplaceAllStringFunc(v, func(match string) string {
+			name := varsRegex.FindStringSubmatch(match)[1]
+			val, ok := vars[name]
+			if !ok {
+				missing[name] = true
+				return match
+			}
+			return fmt.Sprint(val)
+		})
+	}
+
+	return value
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/deployer/models"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeReleaseFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "release")
+	assert.NoError(t, err)
+
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	return dir
+}
+
+var baseRelease = `{
+  "project_name": "project",
+  "config_name": "development",
+  "ami": "ami-123456",
+  "subnets": ["{{vars.subnet}}"],
+  "user_data": "{{USER_DATA_FILE}}",
+  "vars": {
+    "subnet": "subnet-dev",
+    "instance_type": "t2.small",
+    "max": 2
+  },
+  "services": {
+    "web": {
+      "instance_type": "{{vars.instance_type}}",
+      "security_groups": ["web-sg"],
+      "autoscaling": {
+        "min_size": 1,
+        "max_size": "{{vars.max}}"
+      }
+    }
+  }
+}`
+
+func Test_overlayPath(t *testing.T) {
+	assert.Equal(t, "release.production.json", overlayPath("release.json", "production"))
+	assert.Equal(t, "release.production.yaml", overlayPath("release.yaml", "production"))
+	assert.Equal(t, "dir/release.production", overlayPath("dir/release", "production"))
+}
+
+func Test_releaseFromFileOrJSON_Vars(t *testing.T) {
+	dir := writeReleaseFiles(t, map[string]string{
+		"release.json":          baseRelease,
+		"release.json.userdata": "echo {{vars.instance_type}}",
+	})
+	defer os.RemoveAll(dir)
+
+	release, err := releaseFromFileOrJSON(to.Strp(filepath.Join(dir, "release.json")), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "development", *release.ConfigName)
+	assert.Equal(t, "subnet-dev", *release.Subnets[0])
+	assert.Equal(t, "t2.small", *release.Services["web"].InstanceType)
+	assert.Equal(t, 2, release.Services["web"].Autoscaling.MaxSizeInt())
+	assert.Equal(t, "echo t2.small", *release.UserData)
+}
+
+func Test_releaseFromFileOrJSON_Overlay(t *testing.T) {
+	dir := writeReleaseFiles(t, map[string]string{
+		"release.json":          baseRelease,
+		"release.json.userdata": "echo {{vars.instance_type}}",
+		"release.production.json": `{
+      "config_name": "production",
+      "vars": { "subnet": "subnet-prod", "max": 10 },
+      "services": { "web": { "instance_type": "c5.large" } }
+    }`,
+	})
+	defer os.RemoveAll(dir)
+
+	release, err := releaseFromFileOrJSON(to.Strp(filepath.Join(dir, "release.json")), to.Strp("production"), to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "production", *release.ConfigName)
+	assert.Equal(t, "subnet-prod", *release.Subnets[0])
+	assert.Equal(t, "c5.large", *release.Services["web"].InstanceType)
+	assert.Equal(t, 10, release.Services["web"].Autoscaling.MaxSizeInt())
+	assert.Equal(t, 1, release.Services["web"].Autoscaling.MinSizeInt())
+	assert.Equal(t, "web-sg", *release.Services["web"].SecurityGroups[0])
+
+	// vars not in the overlay come from the base
+	assert.Equal(t, "echo t2.small", *release.UserData)
+}
+
+func Test_releaseFromFileOrJSON_MissingVars(t *testing.T) {
+	_, err := releaseFromFileOrJSON(to.Strp(`{
+    "project_name": "project",
+    "config_name": "{{vars.config}}",
+    "subnets": ["{{vars.subnet}}"]
+  }`), nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+
+	assert.Error(t, err)
+	assert.Equal(t, "Missing vars: config, subnet", err.Error())
+}
+
+func Test_releaseFromFileOrJSON_MissingOverlay(t *testing.T) {
+	dir := writeReleaseFiles(t, map[string]string{"release.json": baseRelease})
+	defer os.RemoveAll(dir)
+
+	_, err := releaseFromFileOrJSON(to.Strp(filepath.Join(dir, "release.json")), to.Strp("staging"), to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.Error(t, err)
+
+	_, err = releaseFromFileOrJSON(to.Strp(baseRelease), to.Strp("staging"), to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.Error(t, err)
+}
+
+func Test_releaseFromFileOrJSON_YAML(t *testing.T) {
+	dir := writeReleaseFiles(t, map[string]string{
+		"release.yaml": `
+project_name: project
+config_name: development
+user_data: echo DATE
+subnets:
+  - subnet-1
+services:
+  web:
+    instance_type: t2.small
+    security_groups: [web-sg]
+    autoscaling:
+      max_size: 2
+`,
+		"release.production.yaml": `
+config_name: production
+services:
+  web:
+    autoscaling:
+      spread: high
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "release.yaml")
+	release, err := releaseFromFileOrJSON(&path, nil, to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, release.Services["web"].Autoscaling.MaxSizeInt())
+
+	_, err = releaseFromFileOrJSON(&path, to.Strp("production"), to.Strp("region"), to.Strp("account"), models.DefaultIdentity())
+	assert.Error(t, err)
+	assert.Equal(t, filepath.Join(dir, "release.production.yaml")+":6:7 services.web.autoscaling.spread: expected number got string", err.Error())
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/asg"
+	"github.com/coinbase/step-asg-deployer/aws/gc"
 	"github.com/coinbase/step-asg-deployer/deployer/models"
 	"github.com/coinbase/step/utils/to"
 )
@@ -25,6 +27,19 @@ func (e *ErrorWrapper) Error() string {
 	return fmt.Sprintf("ERROR: %v", e.err)
 }
 
+// addRetries adds the AWS calls retried before the error to its message,
+// the release is not returned with an error to record them
+func (e *ErrorWrapper) addRetries(retries int64) {
+	if retries > 0 {
+		e.err = fmt.Errorf("%v (%v AWS retries)", e.err, retries)
+	}
+}
+
+// retriesError is an error that can record the AWS calls retried
+type retriesError interface {
+	addRetries(int64)
+}
+
 // BadReleaseError error
 type BadReleaseError struct {
 	*ErrorWrapper
@@ -55,11 +70,24 @@ type HaltError struct {
 	*ErrorWrapper
 }
 
+// TimeoutError error
+type TimeoutError struct {
+	*ErrorWrapper
+}
+
 // CleanUpError error
 type CleanUpError struct {
 	*ErrorWrapper
 }
 
+// haltError returns a TimeoutError if the release took too long, otherwise a HaltError
+func haltError(err error) error {
+	if _, ok := err.(*models.TimeoutError); ok {
+		return &TimeoutError{&ErrorWrapper{err}}
+	}
+	return &HaltError{&ErrorWrapper{err}}
+}
+
 func throw(err error) error {
 	fmt.Printf("%v: %v\n", to.ErrorType(err), err.Error())
 	return err
@@ -69,7 +97,41 @@ func throw(err error) error {
 // HANDLERS
 ////////////
 
-var assumedRole = to.Strp("coinbase-step-asg-deployer-assumed")
+// identity of the deployer is configured with the Lambda's environment
+var identity = lambdaIdentity()
+
+var assumedRole = identity.AssumedRole
+
+func lambdaIdentity() *models.Identity {
+	identity := models.IdentityFromEnv()
+	identity.SetDefaults()
+	return identity
+}
+
+// withRetries adds the AWS calls retried by the handler to the release
+func withRetries(awsc aws.Clients, handler DeployHandler) DeployHandler {
+	return func(ctx context.Context, release *models.Release) (*models.Release, error) {
+		start := awsc.Retries()
+
+		release, err := handler(ctx, release)
+		if err != nil {
+			// Only the error is kept when a handler fails so it records the retries
+			if rerr, ok := err.(retriesError); ok {
+				rerr.addRetries(awsc.Retries() - start)
+			}
+			return nil, err
+		}
+
+		if retries := awsc.Retries() - start; retries > 0 {
+			if release.AwsRetries != nil {
+				retries += *release.AwsRetries
+			}
+			release.AwsRetries = &retries
+		}
+
+		return release, nil
+	}
+}
 
 // Validate checks the release for issues
 func Validate(awsc aws.Clients) DeployHandler {
@@ -78,7 +140,9 @@ func Validate(awsc aws.Clients) DeployHandler {
 		release.ReleaseSHA256 = to.SHA256Struct(release)
 
 		// Default the releases Account and Region to where the Lambda is running
-		release.SetDefaultRegionAccount(to.AwsRegionAccountFromContext(ctx))
+		region, account := to.AwsRegionAccountFromContext(ctx)
+		release.SetDefaultRegionAccount(region, account)
+		release.SetDefaultBucket(identity, account)
 		release.SetUUID()     // Ensure that this is set by Server
 		release.SetDefaults() // Fill in all the blank Attributes
 
@@ -86,6 +150,8 @@ func Validate(awsc aws.Clients) DeployHandler {
 			return nil, throw(&BadReleaseError{&ErrorWrapper{err}})
 		}
 
+		release.SelectServices() // Only deploy the services in only_services
+
 		return release, nil
 	}
 }
@@ -119,19 +185,27 @@ func ValidateResources(awsc aws.Clients) DeployHandler {
 
 		// Fetch all Resource Objecgs from AWS, i.e. Security Group, ELBs, Albs, IAM Profile
 		resources, err := release.FetchResources(
-			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			asg.Cached(awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole)),
 			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.IAMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.SNSClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.SQSClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.SSMClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.SecretsManagerClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 		)
 
 		if err != nil {
 			return nil, throw(&BadReleaseError{&ErrorWrapper{err}})
 		}
 
-		if err := release.ValidateResources(resources); err != nil {
+		policy, err := models.LoadDeployerPolicy(awsc.S3Client(nil, nil, nil), release.Bucket)
+		if err != nil {
+			return nil, throw(&BadReleaseError{&ErrorWrapper{err}})
+		}
+
+		if err := release.ValidateResources(resources, policy); err != nil {
 			return nil, throw(&BadReleaseError{&ErrorWrapper{err}})
 		}
 
@@ -148,7 +222,7 @@ func Deploy(awsc aws.Clients) DeployHandler {
 		release.SetDefaults() // Wire up non-serialized relationships
 
 		if err := release.IsHalt(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, throw(&HaltError{&ErrorWrapper{err}})
+			return nil, throw(haltError(err))
 		}
 
 		if err := release.CreateResources(
@@ -167,11 +241,18 @@ func CheckHealthy(awsc aws.Clients) DeployHandler {
 	return func(_ context.Context, release *models.Release) (*models.Release, error) {
 		release.SetDefaults() // Wire up non-serialized relationships
 
+		// Checked before halt so the time paused extends the timeout
+		paused, err := release.UpdatePaused(awsc.S3Client(nil, nil, nil))
+		if err != nil {
+			// This will retry a few times, as it might just be an AWS issue
+			return nil, throw(&HealthError{&ErrorWrapper{err}})
+		}
+
 		if err := release.IsHalt(awsc.S3Client(nil, nil, nil)); err != nil {
-			return nil, throw(&HaltError{&ErrorWrapper{err}})
+			return nil, throw(haltError(err))
 		}
 
-		err := release.UpdateHealthy(
+		err = release.UpdateHealthy(
 			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.ELBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 			awsc.ALBClient(release.AwsRegion, release.AwsAccountID, assumedRole),
@@ -183,11 +264,21 @@ func CheckHealthy(awsc aws.Clients) DeployHandler {
 				// This will immediately stop checking and fail the deploy
 				return nil, throw(&HaltError{&ErrorWrapper{err}})
 			default:
+				if aws.IsPermanentError(err) {
+					// Retrying will not help so fail the deploy
+					return nil, throw(&DeployError{&ErrorWrapper{err}})
+				}
 				// This will retry a few times, as it might just be an AWS issue
 				return nil, throw(&HealthError{&ErrorWrapper{err}})
 			}
 		}
 
+		if paused {
+			// Keep checking health but do not clean up or deploy the next stage
+			release.Healthy = to.Boolp(false)
+			release.NextStage = to.Boolp(false)
+		}
+
 		return release, nil
 	}
 }
@@ -198,7 +289,7 @@ func CleanUpSuccess(awsc aws.Clients) DeployHandler {
 		release.SetDefaults() // Wire up non-serialized relationships
 
 		if err := release.SuccessfulTearDown(
-			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			asg.Cached(awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole)),
 			awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 		); err != nil {
 			return nil, throw(&CleanUpError{&ErrorWrapper{err}})
@@ -208,7 +299,8 @@ func CleanUpSuccess(awsc aws.Clients) DeployHandler {
 			return nil, throw(&LockError{&ErrorWrapper{err}})
 		}
 
-		release.RemoveHalt(awsc.S3Client(nil, nil, nil)) // Delete Halt
+		release.RemoveHalt(awsc.S3Client(nil, nil, nil))  // Delete Halt
+		release.RemovePause(awsc.S3Client(nil, nil, nil)) // Delete Pause
 
 		release.Success = to.Boolp(true) // Wait till the end to mark success
 
@@ -223,8 +315,17 @@ func CleanUpFailure(awsc aws.Clients) DeployHandler {
 
 		release.Success = to.Boolp(false) // Quickly Mark Failure
 
+		asgc := asg.Cached(awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole))
+
+		// Best effort, diagnostics must not stop the clean up
+		release.Diagnose(
+			asgc,
+			awsc.EC2Client(release.AwsRegion, release.AwsAccountID, assumedRole),
+			awsc.S3Client(nil, nil, nil),
+		)
+
 		if err := release.UnsuccssfulTearDown(
-			awsc.ASGClient(release.AwsRegion, release.AwsAccountID, assumedRole),
+			asgc,
 			awsc.CWClient(release.AwsRegion, release.AwsAccountID, assumedRole),
 		); err != nil {
 			return nil, throw(&CleanUpError{&ErrorWrapper{err}})
@@ -243,8 +344,36 @@ func ReleaseLockFailure(awsc aws.Clients) DeployHandler {
 			return nil, throw(&LockError{&ErrorWrapper{err}})
 		}
 
-		release.RemoveHalt(awsc.S3Client(nil, nil, nil)) // Delete Halt
+		release.RemoveHalt(awsc.S3Client(nil, nil, nil))  // Delete Halt
+		release.RemovePause(awsc.S3Client(nil, nil, nil)) // Delete Pause
 
 		return release, nil
 	}
 }
+
+////////////
+// GC
+////////////
+
+// GCHandler function type
+type GCHandler func(context.Context, *gc.Input) (*gc.Report, error)
+
+// GC deletes orphaned launch configurations and alarms, it is run on a schedule not by the state machine
+func GC(awsc aws.Clients) GCHandler {
+	return func(ctx context.Context, input *gc.Input) (*gc.Report, error) {
+		region, accountID := to.AwsRegionAccountFromContext(ctx)
+
+		report, err := gc.Collect(
+			awsc.ASGClient(region, accountID, assumedRole),
+			awsc.CWClient(region, accountID, assumedRole),
+			input.MaxAgeDuration(),
+			input != nil && input.DryRun,
+		)
+
+		if err != nil {
+			return nil, throw(err)
+		}
+
+		return report, nil
+	}
+}
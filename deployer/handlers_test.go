@@ -1,10 +1,13 @@
 package deployer
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/coinbase/step-asg-deployer/aws/mocks"
 	"github.com/coinbase/step-asg-deployer/deployer/models"
 	"github.com/coinbase/step/utils/to"
@@ -72,6 +75,56 @@ func Test_ValidateResources_BadTG(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// Test that validate resources fails if a security group breaks the deployer policy
+func Test_ValidateResources_DeployerPolicy(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+
+	awsc := models.MockAwsClients(release)
+	awsc.EC2.AddSecurityGroupIngress("web-sg", &ec2.IpPermission{
+		IpProtocol: to.Strp("tcp"),
+		FromPort:   to.Int64p(22),
+		ToPort:     to.Int64p(22),
+		IpRanges:   []*ec2.IpRange{&ec2.IpRange{CidrIp: to.Strp("0.0.0.0/0")}},
+	})
+
+	_, err := ValidateResources(awsc)(nil, release)
+	assert.NoError(t, err)
+
+	awsc.S3.AddGetObject(*models.DeployerPolicyPath, `{"security_groups": {"deny_rules": [{"cidr": "0.0.0.0/0", "port": 22}]}}`, nil)
+	_, err = ValidateResources(awsc)(nil, release)
+	assert.Error(t, err)
+	assert.IsType(t, &BadReleaseError{}, err)
+}
+
+// Test a halt between stages fails Deploy and cleans up the earlier stages
+func Test_Deploy_HaltBetweenStages(t *testing.T) {
+	release := models.MockRelease(t)
+	release.Services["worker"] = &models.Service{
+		InstanceType: to.Strp("t2.small"),
+		DependsOn:    []*string{to.Strp("web")},
+	}
+	models.MockPrepareRelease(release)
+
+	// web was created and is healthy so worker is next
+	web := "project-config-web-stage-1"
+	release.Services["web"].CreatedASG = to.Strp(web)
+	release.Services["web"].Healthy = true
+	release.NextStage = to.Boolp(true)
+
+	awsc := models.MockAwsClients(release)
+	awsc.ASG.AddASG(mocks.MakeMockASG(web, *release.ProjectName, *release.ConfigName, "web", *release.ReleaseID))
+	assert.NoError(t, release.Halt(awsc.S3, nil))
+
+	_, err := Deploy(awsc)(nil, release)
+	assert.IsType(t, &HaltError{}, err)
+	assert.Nil(t, release.Services["worker"].CreatedASG)
+
+	_, err = CleanUpFailure(awsc)(nil, release)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{web}, to.StrSlice(awsc.ASG.DeletedASGs))
+}
+
 // Test Check Healthy
 func Test_CheckHealthy_CorrectReport(t *testing.T) {
 	release := models.MockRelease(t)
@@ -109,3 +162,110 @@ func Test_CheckHealthy_Terming(t *testing.T) {
 	_, err := CheckHealthy(awsc)(nil, release)
 	assert.Error(t, err)
 }
+
+// Test Check Healthy keeps a paused release waiting even when healthy
+func Test_CheckHealthy_Paused(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	release.Services["web"].Resources = &models.ServiceResourceNames{}
+	release.Services["web"].CreatedASG = to.Strp("asd")
+
+	awsc := mocks.MockAWS()
+	awsc.ASG.AddASG(&autoscaling.Group{Instances: mocks.MakeMockASGInstances(2, 3, 0)})
+
+	assert.NoError(t, release.Pause(awsc.S3))
+
+	res, err := CheckHealthy(awsc)(nil, release)
+	assert.NoError(t, err)
+	assert.Equal(t, false, *res.Healthy)
+	assert.Equal(t, true, *res.Paused)
+	assert.Equal(t, 2, *res.Services["web"].HealthReport.Healthy)
+
+	delete(awsc.S3.GetObjectResp, *release.PausePath())
+
+	res, err = CheckHealthy(awsc)(nil, res)
+	assert.NoError(t, err)
+	assert.Equal(t, true, *res.Healthy)
+	assert.Equal(t, false, *res.Paused)
+}
+
+// Test Check Healthy retries when it cannot tell if the release is paused
+func Test_CheckHealthy_PauseError(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	release.Services["web"].Resources = &models.ServiceResourceNames{}
+	release.Services["web"].CreatedASG = to.Strp("asd")
+
+	awsc := mocks.MockAWS()
+	awsc.ASG.AddASG(&autoscaling.Group{Instances: mocks.MakeMockASGInstances(2, 3, 0)})
+	awsc.S3.AddGetObject(*release.PausePath(), "", awserr.New("SlowDown", "Please reduce your request rate", nil))
+
+	_, err := CheckHealthy(awsc)(nil, release)
+	assert.IsType(t, &HealthError{}, err)
+}
+
+// Test Check Healthy fails the deploy on permanent AWS errors without retrying
+func Test_CheckHealthy_PermanentError(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	release.Services["web"].Resources = &models.ServiceResourceNames{}
+	release.Services["web"].CreatedASG = to.Strp("asd")
+
+	awsc := mocks.MockAWS()
+	awsc.ASG.DescribeAutoScalingGroupsPageResp = []mocks.DescribeAutoScalingGroupResponse{
+		{Error: awserr.New("ValidationError", "bad input", nil)},
+	}
+
+	_, err := CheckHealthy(awsc)(nil, release)
+	assert.IsType(t, &DeployError{}, err)
+
+	awsc.ASG.DescribeAutoScalingGroupsPageResp = []mocks.DescribeAutoScalingGroupResponse{
+		{Error: awserr.New("Throttling", "Rate exceeded", nil)},
+	}
+
+	_, err = CheckHealthy(awsc)(nil, release)
+	assert.IsType(t, &HealthError{}, err)
+}
+
+func Test_haltError(t *testing.T) {
+	release := models.MockRelease(t)
+	models.MockPrepareRelease(release)
+	awsc := mocks.MockAWS()
+
+	release.Timeout = to.Intp(-10)
+	_, err := CheckHealthy(awsc)(nil, release)
+	assert.IsType(t, &TimeoutError{}, err)
+
+	release.Timeout = to.Intp(600)
+	assert.NoError(t, release.Halt(awsc.S3, &models.HaltRequest{Reason: to.Strp("Timeout in checkout")}))
+	_, err = CheckHealthy(awsc)(nil, release)
+	assert.IsType(t, &HaltError{}, err)
+}
+
+func Test_withRetries(t *testing.T) {
+	release := models.MockRelease(t)
+	awsc := mocks.MockAWS()
+
+	retrying := func(_ context.Context, release *models.Release) (*models.Release, error) {
+		awsc.RetryCount += 2
+		return release, nil
+	}
+
+	res, err := withRetries(awsc, retrying)(nil, release)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), *res.AwsRetries)
+
+	res, err = withRetries(awsc, retrying)(nil, res)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), *res.AwsRetries)
+
+	// A failed handler does not return the release so the error records them
+	failing := func(_ context.Context, release *models.Release) (*models.Release, error) {
+		awsc.RetryCount += 3
+		return nil, &HealthError{&ErrorWrapper{fmt.Errorf("Throttling")}}
+	}
+
+	_, err = withRetries(awsc, failing)(nil, res)
+	assert.IsType(t, &HealthError{}, err)
+	assert.Equal(t, "ERROR: Throttling (3 AWS retries)", err.Error())
+}
@@ -61,6 +61,8 @@ func Test_UnsuccessfulDeploy_Execution_Works(t *testing.T) {
 		"ValidateResources",
 		"DeployFn",
 		"Deploy",
+		"CleanUpFailureFn",
+		"CleanUpFailure",
 		"ReleaseLockFailureFn",
 		"ReleaseLockFailure",
 		"FailureClean",
@@ -145,6 +147,29 @@ func Test_Execution_FetchDeploy_LockError(t *testing.T) {
 	})
 }
 
+func Test_Execution_FetchDeploy_LegacyLockError(t *testing.T) {
+	release := models.MockRelease(t)
+
+	// A release started before the upgrade holds the lock without the account and region
+	awsClients := models.MockAwsClients(release)
+	awsClients.S3.AddGetObject("project/config/lock", `{"uuid": "already"}`, nil)
+
+	stateMachine := createTestStateMachine(t, awsClients)
+
+	output, err := stateMachine.ExecuteToMap(release)
+
+	assert.Error(t, err)
+	assert.Equal(t, "FailureClean", output["Error"])
+
+	assert.Equal(t, stateMachine.ExecutionPath(), []string{
+		"ValidateFn",
+		"Validate",
+		"LockFn",
+		"Lock",
+		"FailureClean",
+	})
+}
+
 func Test_Execution_CheckHealthy_HaltError_WithTermination(t *testing.T) {
 	// Should end in Alert Bad Thing Happened State
 	release := models.MockRelease(t)
@@ -94,16 +94,10 @@ func StateMachine() (*machine.StateMachine, error) {
         "Next": "WaitForDeploy",
         "Catch": [
           {
-            "Comment": "Try to Release Locks and Cleanup any created Resources",
-            "ErrorEquals": ["DeployError", "PanicError"],
+            "Comment": "Try to Release Locks and Cleanup any created Resources, including earlier stages",
+            "ErrorEquals": ["DeployError", "HaltError", "TimeoutError", "PanicError"],
             "ResultPath": "$.error",
             "Next": "CleanUpFailureFn"
-          },
-          {
-            "Comment": "Try to Release Locks",
-            "ErrorEquals": ["HaltError"],
-            "ResultPath": "$.error",
-            "Next": "ReleaseLockFailureFn"
           }
         ]
       },
@@ -136,13 +130,13 @@ func StateMachine() (*machine.StateMachine, error) {
         }],
         "Catch": [{
           "Comment": "HaltError immediately Clean up",
-          "ErrorEquals": ["HaltError", "HealthError", "PanicError"],
+          "ErrorEquals": ["HaltError", "TimeoutError", "HealthError", "DeployError", "PanicError"],
           "ResultPath": "$.error",
           "Next": "CleanUpFailureFn"
         }]
       },
       "Healthy?": {
-        "Comment": "Check the release is $.healthy",
+        "Comment": "Check the release is $.healthy or ready for the $.next_stage",
         "Type": "Choice",
         "Choices": [
           {
@@ -150,6 +144,11 @@ func StateMachine() (*machine.StateMachine, error) {
             "BooleanEquals": true,
             "Next": "CleanUpSuccessFn"
           },
+          {
+            "Variable": "$.next_stage",
+            "BooleanEquals": true,
+            "Next": "DeployFn"
+          },
           {
             "Variable": "$.healthy",
             "BooleanEquals": false,
@@ -247,6 +246,9 @@ func StateMachine() (*machine.StateMachine, error) {
 	return stateMachine, nil
 }
 
+// gcTask is the Task the scheduled event sends to the Lambda to run GC
+const gcTask = "GC"
+
 // StateMachineWithTaskHandlers returns
 func StateMachineWithTaskHandlers(tfs *handler.TaskFunctions) (*machine.StateMachine, error) {
 	stateMachine, err := StateMachine()
@@ -255,6 +257,10 @@ func StateMachineWithTaskHandlers(tfs *handler.TaskFunctions) (*machine.StateMac
 	}
 
 	for name, smhandler := range *tfs {
+		if name == gcTask {
+			continue // GC is run on a schedule and is not a state
+		}
+
 		if err := stateMachine.SetResourceFunction(name, smhandler); err != nil {
 			return nil, err
 		}
@@ -272,13 +278,14 @@ func TaskFunctions() *handler.TaskFunctions {
 // CreateTaskFunctinons returns
 func CreateTaskFunctinons(awsClients aws.Clients) *handler.TaskFunctions {
 	tm := handler.TaskFunctions{}
-	tm["Validate"] = Validate(awsClients)
-	tm["Lock"] = Lock(awsClients)
-	tm["ValidateResources"] = ValidateResources(awsClients)
-	tm["Deploy"] = Deploy(awsClients)
-	tm["CheckHealthy"] = CheckHealthy(awsClients)
-	tm["CleanUpSuccess"] = CleanUpSuccess(awsClients)
-	tm["CleanUpFailure"] = CleanUpFailure(awsClients)
-	tm["ReleaseLockFailure"] = ReleaseLockFailure(awsClients)
+	tm["Validate"] = withRetries(awsClients, Validate(awsClients))
+	tm["Lock"] = withRetries(awsClients, Lock(awsClients))
+	tm["ValidateResources"] = withRetries(awsClients, ValidateResources(awsClients))
+	tm["Deploy"] = withRetries(awsClients, Deploy(awsClients))
+	tm["CheckHealthy"] = withRetries(awsClients, CheckHealthy(awsClients))
+	tm["CleanUpSuccess"] = withRetries(awsClients, CleanUpSuccess(awsClients))
+	tm["CleanUpFailure"] = withRetries(awsClients, CleanUpFailure(awsClients))
+	tm["ReleaseLockFailure"] = withRetries(awsClients, ReleaseLockFailure(awsClients))
+	tm[gcTask] = GC(awsClients)
 	return &tm
 }
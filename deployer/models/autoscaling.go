@@ -1,18 +1,17 @@
 package models
 
 import (
-	"fmt"
-
 	"github.com/coinbase/step/utils/to"
 )
 
 // AutoScalingConfig struct
 type AutoScalingConfig struct {
-	MinSize         *int64    `json:"min_size,omitempty"`
-	MaxSize         *int64    `json:"max_size,omitempty"`
-	MaxTerminations *int64    `json:"max_terms,omitempty"`
-	Spread          *float64  `json:"spread,omitempty"`
-	Policies        []*Policy `json:"policies,omitempty"`
+	MinSize           *int64    `json:"min_size,omitempty"`
+	MaxSize           *int64    `json:"max_size,omitempty"`
+	MaxTerminations   *int64    `json:"max_terms,omitempty"`
+	MaxFailedLaunches *int64    `json:"max_failed_launches,omitempty"`
+	Spread            *float64  `json:"spread,omitempty"`
+	Policies          []*Policy `json:"policies,omitempty"`
 }
 
 // MinSizeInt returns min size
@@ -39,35 +38,47 @@ func (a *AutoScalingConfig) MaxTerminationsInt() int {
 	return int(*a.MaxTerminations)
 }
 
+// MaxFailedLaunchesInt returns maximum failed launches allowed that might succeed if retried
+func (a *AutoScalingConfig) MaxFailedLaunchesInt() int {
+	if a.MaxFailedLaunches == nil {
+		return 2
+	}
+	return int(*a.MaxFailedLaunches)
+}
+
 // ValidateAttributes validates attributes
 func (a *AutoScalingConfig) ValidateAttributes() error {
 	if a.MinSize == nil {
-		return fmt.Errorf("Autoscaling MinSize is nil")
+		return fieldErrorf("min_size", "Autoscaling MinSize is nil")
 	}
 
 	if a.MaxSize == nil {
-		return fmt.Errorf("Autoscaling MaxSize is nil")
+		return fieldErrorf("max_size", "Autoscaling MaxSize is nil")
 	}
 
 	if a.Spread == nil {
-		return fmt.Errorf("Autoscaling Spread is nil")
+		return fieldErrorf("spread", "Autoscaling Spread is nil")
 	}
 
 	if *a.MinSize > *a.MaxSize {
-		return fmt.Errorf("Autoscaling MinSize is Greater than MaxSize")
+		return fieldErrorf("min_size", "Autoscaling MinSize is Greater than MaxSize")
 	}
 
 	if *a.Spread < 0 || *a.Spread > 1 {
-		return fmt.Errorf("Spread must be between 0 and 1")
+		return fieldErrorf("spread", "Spread must be between 0 and 1")
 	}
 
-	for _, p := range a.Policies {
+	if a.MaxFailedLaunches != nil && *a.MaxFailedLaunches < 0 {
+		return fieldErrorf("max_failed_launches", "MaxFailedLaunches must be 0 or more")
+	}
+
+	for i, p := range a.Policies {
 		if p == nil {
-			return fmt.Errorf("Policy nil")
+			return fieldErrorf(indexPath("policies", i), "Policy nil")
 		}
 
 		if err := p.ValidateAttributes(); err != nil {
-			return err
+			return inPath(indexPath("policies", i), err)
 		}
 	}
 	return nil
@@ -91,6 +102,10 @@ func (a *AutoScalingConfig) SetDefaults(serviceID *string) error {
 		a.MaxTerminations = to.Int64p(0)
 	}
 
+	if a.MaxFailedLaunches == nil {
+		a.MaxFailedLaunches = to.Int64p(2)
+	}
+
 	for _, p := range a.Policies {
 		if p != nil {
 			p.SetDefaults(serviceID)
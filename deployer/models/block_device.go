@@ -0,0 +1,193 @@
+package models
+
+import (
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+var volumeTypes = []string{"gp2", "gp3", "io1", "io2", "st1", "sc1", "standard"}
+
+var instanceStoreRegex = regexp.MustCompile(`^ephemeral[0-9]+$`)
+
+// BlockDevice is an EBS volume or instance store attached to the service's instances
+type BlockDevice struct {
+	DeviceName *string `json:"device_name,omitempty"`
+
+	// Instance store volumes are named ephemeral0 to ephemeral23
+	VirtualName *string `json:"virtual_name,omitempty"`
+
+	// EBS
+	VolumeSize          *int64  `json:"volume_size,omitempty"`
+	VolumeType          *string `json:"volume_type,omitempty"`
+	Iops                *int64  `json:"iops,omitempty"`
+	Throughput          *int64  `json:"throughput,omitempty"` // MiB/s only gp3
+	Encrypted           *bool   `json:"encrypted,omitempty"`  // With the accounts default EBS key
+	DeleteOnTermination *bool   `json:"delete_on_termination,omitempty"`
+}
+
+// SetDefaults assigns default values
+func (bd *BlockDevice) SetDefaults() {
+	if bd.isInstanceStore() {
+		return
+	}
+
+	if bd.VolumeType == nil {
+		bd.VolumeType = to.Strp("gp2")
+	}
+
+	if bd.DeleteOnTermination == nil {
+		bd.DeleteOnTermination = to.Boolp(true)
+	}
+}
+
+func (bd *BlockDevice) isInstanceStore() bool {
+	return bd.VirtualName != nil
+}
+
+// ToBlockDeviceMapping returns the launch configuration mapping
+func (bd *BlockDevice) ToBlockDeviceMapping() *autoscaling.BlockDeviceMapping {
+	if bd.isInstanceStore() {
+		return &autoscaling.BlockDeviceMapping{
+			DeviceName:  bd.DeviceName,
+			VirtualName: bd.VirtualName,
+		}
+	}
+
+	return &autoscaling.BlockDeviceMapping{
+		DeviceName: bd.DeviceName,
+		Ebs: &autoscaling.Ebs{
+			VolumeSize:          bd.VolumeSize,
+			VolumeType:          bd.VolumeType,
+			Iops:                bd.Iops,
+			Throughput:          bd.Throughput,
+			Encrypted:           bd.Encrypted,
+			DeleteOnTermination: bd.DeleteOnTermination,
+		},
+	}
+}
+
+// ValidateAttributes validates attributes
+func (bd *BlockDevice) ValidateAttributes() error {
+	if is.EmptyStr(bd.DeviceName) {
+		return fieldErrorf("device_name", "DeviceName must be defined")
+	}
+
+	if bd.isInstanceStore() {
+		if !instanceStoreRegex.MatchString(*bd.VirtualName) {
+			return fieldErrorf("virtual_name", "VirtualName must be ephemeral0 to ephemeral23")
+		}
+
+		if bd.VolumeSize != nil || bd.VolumeType != nil || bd.Iops != nil || bd.Throughput != nil || bd.Encrypted != nil || bd.DeleteOnTermination != nil {
+			return fieldErrorf("virtual_name", "Instance store volumes cannot have EBS attributes")
+		}
+
+		return nil
+	}
+
+	if bd.VolumeSize == nil || *bd.VolumeSize < 1 {
+		return fieldErrorf("volume_size", "VolumeSize must be greater than 0")
+	}
+
+	volumeType := to.Strs(bd.VolumeType)
+	if !isVolumeType(volumeType) {
+		return fieldErrorf("volume_type", "VolumeType %q must be one of %v", volumeType, volumeTypes)
+	}
+
+	if err := bd.validateSize(volumeType); err != nil {
+		return err
+	}
+
+	switch volumeType {
+	case "io1", "io2":
+		if bd.Iops == nil {
+			return fieldErrorf("iops", "Iops must be defined for %v", volumeType)
+		}
+	case "gp3":
+	default:
+		if bd.Iops != nil {
+			return fieldErrorf("iops", "Iops is only supported by gp3, io1 and io2")
+		}
+	}
+
+	if bd.Throughput != nil && volumeType != "gp3" {
+		return fieldErrorf("throughput", "Throughput is only supported by gp3")
+	}
+
+	if err := bd.validateIops(volumeType); err != nil {
+		return err
+	}
+
+	if bd.Throughput != nil && (*bd.Throughput < 125 || *bd.Throughput > 1000) {
+		return fieldErrorf("throughput", "Throughput must be between 125 and 1000")
+	}
+
+	return nil
+}
+
+// validateSize checks the minimum size of the volume type in GiB
+func (bd *BlockDevice) validateSize(volumeType string) error {
+	min := int64(1)
+	switch volumeType {
+	case "io1", "io2":
+		min = 4
+	case "st1", "sc1":
+		min = 125
+	}
+
+	if *bd.VolumeSize < min {
+		return fieldErrorf("volume_size", "VolumeSize for %v must be at least %v", volumeType, min)
+	}
+
+	return nil
+}
+
+// validateIops checks the IOPS are in the range of the volume type
+func (bd *BlockDevice) validateIops(volumeType string) error {
+	if bd.Iops == nil {
+		return nil
+	}
+
+	min, max := int64(100), int64(64000)
+	switch volumeType {
+	case "gp3":
+		min, max = 3000, 80000
+	case "io2":
+		max = 256000
+	}
+
+	if *bd.Iops < min || *bd.Iops > max {
+		return fieldErrorf("iops", "Iops for %v must be between %v and %v", volumeType, min, max)
+	}
+
+	return nil
+}
+
+func isVolumeType(volumeType string) bool {
+	for _, vt := range volumeTypes {
+		if vt == volumeType {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBlockDeviceNames returns an error if a device name is used more than once
+func validateBlockDeviceNames(devices []*BlockDevice, legacyDeviceName *string) error {
+	seen := map[string]bool{}
+	if legacyDeviceName != nil {
+		seen[*legacyDeviceName] = true
+	}
+
+	for i, bd := range devices {
+		name := to.Strs(bd.DeviceName)
+		if seen[name] {
+			return fieldErrorf(indexPath("block_devices", i)+".device_name", "DeviceName %q is used more than once", name)
+		}
+		seen[name] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BlockDevice_ValidateAttributes(t *testing.T) {
+	valid := func() *BlockDevice {
+		bd := &BlockDevice{DeviceName: to.Strp("/dev/sdb"), VolumeSize: to.Int64p(100)}
+		bd.SetDefaults()
+		return bd
+	}
+
+	assert.NoError(t, valid().ValidateAttributes())
+	assert.Equal(t, "gp2", *valid().VolumeType)
+	assert.True(t, *valid().DeleteOnTermination)
+
+	bd := valid()
+	bd.DeviceName = nil
+	assert.Equal(t, "device_name", bd.ValidateAttributes().(*FieldError).Path)
+
+	bd = valid()
+	bd.VolumeType = to.Strp("gp1")
+	assert.Equal(t, "volume_type", bd.ValidateAttributes().(*FieldError).Path)
+
+	// io volumes need iops
+	bd = valid()
+	bd.VolumeType = to.Strp("io2")
+	assert.Equal(t, "iops", bd.ValidateAttributes().(*FieldError).Path)
+	bd.Iops = to.Int64p(4000)
+	assert.NoError(t, bd.ValidateAttributes())
+	bd.Iops = to.Int64p(99)
+	assert.Equal(t, "iops", bd.ValidateAttributes().(*FieldError).Path)
+	bd.Iops = to.Int64p(100000)
+	assert.NoError(t, bd.ValidateAttributes())
+
+	bd.VolumeType = to.Strp("io1")
+	assert.Equal(t, "iops", bd.ValidateAttributes().(*FieldError).Path)
+
+	// gp3 can have iops and throughput
+	bd = valid()
+	bd.VolumeType = to.Strp("gp3")
+	bd.Iops = to.Int64p(3000)
+	bd.Throughput = to.Int64p(250)
+	bd.Encrypted = to.Boolp(true)
+	assert.NoError(t, bd.ValidateAttributes())
+
+	// gp3 includes 3000 IOPS
+	bd.Iops = to.Int64p(1000)
+	assert.Equal(t, "iops", bd.ValidateAttributes().(*FieldError).Path)
+	bd.Iops = to.Int64p(3000)
+
+	bd.VolumeType = to.Strp("gp2")
+	assert.Equal(t, "iops", bd.ValidateAttributes().(*FieldError).Path)
+
+	bd.Iops = nil
+	assert.Equal(t, "throughput", bd.ValidateAttributes().(*FieldError).Path)
+
+	// st1 has a minimum size
+	bd = valid()
+	bd.VolumeType = to.Strp("st1")
+	bd.VolumeSize = to.Int64p(125)
+	assert.NoError(t, bd.ValidateAttributes())
+	bd.VolumeSize = to.Int64p(20)
+	assert.Equal(t, "volume_size", bd.ValidateAttributes().(*FieldError).Path)
+}
+
+func Test_BlockDevice_InstanceStore(t *testing.T) {
+	bd := &BlockDevice{DeviceName: to.Strp("/dev/sdc"), VirtualName: to.Strp("ephemeral0")}
+	bd.SetDefaults()
+	assert.NoError(t, bd.ValidateAttributes())
+
+	bdm := bd.ToBlockDeviceMapping()
+	assert.Equal(t, "ephemeral0", *bdm.VirtualName)
+	assert.Nil(t, bdm.Ebs)
+
+	bd.VolumeSize = to.Int64p(10)
+	assert.Error(t, bd.ValidateAttributes())
+
+	bd = &BlockDevice{DeviceName: to.Strp("/dev/sdc"), VirtualName: to.Strp("disk0")}
+	assert.Error(t, bd.ValidateAttributes())
+}
+
+func Test_Service_BlockDevices(t *testing.T) {
+	r := MockRelease(t)
+	r.Services["web"].BlockDevices = []*BlockDevice{
+		&BlockDevice{DeviceName: to.Strp("/dev/sdb"), VolumeSize: to.Int64p(500), VolumeType: to.Strp("gp3"), Encrypted: to.Boolp(true)},
+		&BlockDevice{DeviceName: to.Strp("/dev/sdc"), VirtualName: to.Strp("ephemeral0")},
+	}
+	MockPrepareRelease(r)
+
+	assert.NoError(t, r.ValidateServices())
+
+	input := r.Services["web"].createLaunchConfigurationInput()
+	assert.Equal(t, 3, len(input.BlockDeviceMappings))
+	assert.Equal(t, "/dev/sdb", *input.BlockDeviceMappings[1].DeviceName)
+	assert.True(t, *input.BlockDeviceMappings[1].Ebs.Encrypted)
+	assert.Equal(t, "ephemeral0", *input.BlockDeviceMappings[2].VirtualName)
+
+	// Cannot reuse the device of ebs_volume_size
+	r.Services["web"].BlockDevices[0].DeviceName = to.Strp("/dev/xvda")
+	err := r.ValidateServices()
+	assert.Error(t, err)
+	assert.Equal(t, "services.web.block_devices[0].device_name", err.(*FieldError).Path)
+}
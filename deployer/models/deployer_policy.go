@@ -0,0 +1,246 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coinbase/step-asg-deployer/aws/ami"
+	"github.com/coinbase/step-asg-deployer/aws/sg"
+	"github.com/coinbase/step/aws"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+)
+
+// The deployer policy is an optional document in the deployer's bucket
+// that adds checks to the resources every release uses.
+// It is not part of a release so only people who can write to the
+// root of the bucket can change it.
+
+// DeployerPolicyPath is the key of the policy in the deployer's bucket
+var DeployerPolicyPath = to.Strp("policy.json")
+
+// DeployerPolicy struct
+type DeployerPolicy struct {
+	SecurityGroups *SecurityGroupPolicy `json:"security_groups,omitempty"`
+	Images         *ImagePolicy         `json:"images,omitempty"`
+	Instances      *InstancePolicy      `json:"instances,omitempty"`
+}
+
+// InstancePolicy restricts how the instances of every service are launched
+type InstancePolicy struct {
+	// RequireIMDSv2 requires metadata_options.http_tokens to be "required" or the endpoint "disabled"
+	RequireIMDSv2 *bool `json:"require_imdsv2,omitempty"`
+}
+
+// ImagePolicy restricts the AMIs releases can use
+type ImagePolicy struct {
+	TrustedOwners    []*string `json:"trusted_owners,omitempty"`
+	DenyDeprecated   *bool     `json:"deny_deprecated,omitempty"`
+	RequireEncrypted *bool     `json:"require_encrypted,omitempty"`
+
+	// RequireApproval requires the image's ApprovedFor tag to include the config_name
+	RequireApproval *bool `json:"require_approval,omitempty"`
+}
+
+// SecurityGroupPolicy restricts the ingress rules of a release's security groups
+type SecurityGroupPolicy struct {
+	DenyRules []*DenyRule `json:"deny_rules,omitempty"`
+
+	// DenyCrossAccount rejects references to groups in other accounts
+	// unless the account is in AllowedAccounts
+	DenyCrossAccount *bool     `json:"deny_cross_account,omitempty"`
+	AllowedAccounts  []*string `json:"allowed_accounts,omitempty"`
+}
+
+// DenyRule matches ingress rules, all defined attributes must match
+type DenyRule struct {
+	CIDR     *string `json:"cidr,omitempty"`
+	Port     *int64  `json:"port,omitempty"`
+	AllPorts *bool   `json:"all_ports,omitempty"`
+}
+
+// LoadDeployerPolicy returns the policy in the bucket, or an empty policy if there is none
+func LoadDeployerPolicy(s3c aws.S3API, bucket *string) (*DeployerPolicy, error) {
+	var policy DeployerPolicy
+	err := s3.GetStruct(s3c, bucket, DeployerPolicyPath, &policy)
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == awss3.ErrCodeNoSuchKey {
+			return &DeployerPolicy{}, nil
+		}
+		return nil, fmt.Errorf("Error Getting Deployer Policy with %v", err.Error())
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// Validate returns an error if the policy cannot be applied
+func (policy *DeployerPolicy) Validate() error {
+	if policy.SecurityGroups == nil {
+		return nil
+	}
+
+	for i, rule := range policy.SecurityGroups.DenyRules {
+		if rule == nil || (rule.CIDR == nil && rule.Port == nil && !isTrue(rule.AllPorts)) {
+			return fmt.Errorf("Deployer Policy security_groups.deny_rules[%v] must define cidr, port or all_ports", i)
+		}
+
+		if rule.CIDR != nil {
+			if _, _, err := net.ParseCIDR(*rule.CIDR); err != nil {
+				return fmt.Errorf("Deployer Policy security_groups.deny_rules[%v].cidr %v is not a CIDR", i, *rule.CIDR)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateSecurityGroup returns an error if any ingress rule of the group is denied
+func (policy *DeployerPolicy) ValidateSecurityGroup(accountID *string, group *sg.SecurityGroup) error {
+	if policy == nil || policy.SecurityGroups == nil || group == nil {
+		return nil
+	}
+
+	sgp := policy.SecurityGroups
+
+	for _, ingress := range group.IngressRules {
+		for _, deny := range sgp.DenyRules {
+			if cidr, ok := deny.matches(ingress); ok {
+				return fmt.Errorf("Security Group %v ingress %v denied by deployer policy", to.Strs(group.GroupID), describeIngress(ingress, cidr))
+			}
+		}
+
+		if !isTrue(sgp.DenyCrossAccount) {
+			continue
+		}
+
+		for i, owner := range ingress.GroupOwners {
+			if owner == nil || *owner == to.Strs(accountID) || sgp.allowedAccount(*owner) {
+				continue
+			}
+
+			return fmt.Errorf("Security Group %v references %v in account %v not allowed by deployer policy", to.Strs(group.GroupID), to.Strs(ingress.GroupIDs[i]), *owner)
+		}
+	}
+
+	return nil
+}
+
+// ValidateImage returns an error if the image is not allowed for the config
+func (policy *DeployerPolicy) ValidateImage(configName *string, im *ami.Image) error {
+	if policy == nil || policy.Images == nil || im == nil {
+		return nil
+	}
+
+	ip := policy.Images
+
+	if len(ip.TrustedOwners) > 0 && !inStrs(to.StrSlice(ip.TrustedOwners), to.Strs(im.OwnerID)) {
+		return fmt.Errorf("Image %v Owner %v not trusted by deployer policy", to.Strs(im.ImageID), to.Strs(im.OwnerID))
+	}
+
+	if isTrue(ip.DenyDeprecated) && im.Deprecated(time.Now()) {
+		return fmt.Errorf("Image %v deprecated at %v", to.Strs(im.ImageID), to.Strs(im.DeprecationTime))
+	}
+
+	if isTrue(ip.RequireEncrypted) && !im.RootEncrypted {
+		return fmt.Errorf("Image %v root volume must be encrypted", to.Strs(im.ImageID))
+	}
+
+	if isTrue(ip.RequireApproval) && !im.ApprovedFor(to.Strs(configName)) {
+		return fmt.Errorf("Image %v ApprovedFor Tag %q does not include %v", to.Strs(im.ImageID), to.Strs(im.ApprovedForTag), to.Strs(configName))
+	}
+
+	return nil
+}
+
+// ValidateService returns an error if the service's instances are not allowed
+func (policy *DeployerPolicy) ValidateService(service *Service) error {
+	if policy == nil || policy.Instances == nil || service == nil {
+		return nil
+	}
+
+	if isTrue(policy.Instances.RequireIMDSv2) && !service.MetadataOptions.requiresIMDSv2() {
+		return fmt.Errorf("Service %v must set metadata_options.http_tokens to \"required\", IMDSv2 is required by deployer policy", to.Strs(service.ServiceName))
+	}
+
+	return nil
+}
+
+func (sgp *SecurityGroupPolicy) allowedAccount(accountID string) bool {
+	return inStrs(to.StrSlice(sgp.AllowedAccounts), accountID)
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// matches returns the matching CIDR (if the rule has one) and whether the ingress is denied
+func (deny *DenyRule) matches(ingress *sg.IngressRule) (string, bool) {
+	if deny == nil || ingress == nil {
+		return "", false
+	}
+
+	if deny.Port != nil && !ingress.HasPort(*deny.Port) {
+		return "", false
+	}
+
+	if isTrue(deny.AllPorts) && !ingress.AllPorts() {
+		return "", false
+	}
+
+	if deny.CIDR == nil {
+		return "", true
+	}
+
+	_, denied, err := net.ParseCIDR(*deny.CIDR)
+	if err != nil {
+		return "", false // Validate rejects policies with invalid CIDRs
+	}
+
+	for _, cidr := range ingress.CIDRs {
+		if cidr != nil && includes(*cidr, denied) {
+			return *cidr, true
+		}
+	}
+
+	return "", false
+}
+
+func describeIngress(ingress *sg.IngressRule, cidr string) string {
+	ports := "all ports"
+	if !ingress.AllPorts() && ingress.FromPort != nil && ingress.ToPort != nil {
+		ports = fmt.Sprintf("%v %v-%v", to.Strs(ingress.Protocol), *ingress.FromPort, *ingress.ToPort)
+	}
+
+	if cidr == "" {
+		return ports
+	}
+
+	return fmt.Sprintf("%v from %v", ports, cidr)
+}
+
+// includes returns true if the CIDR is at least as broad as the denied range,
+// so narrower ranges, e.g. private ones, are allowed. A denied /0 is every address
+// so it is included by any /0, IPv4 or IPv6
+func includes(cidr string, denied *net.IPNet) bool {
+	_, ingress, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+
+	ingressOnes, _ := ingress.Mask.Size()
+	deniedOnes, _ := denied.Mask.Size()
+
+	if deniedOnes == 0 {
+		return ingressOnes == 0
+	}
+
+	return ingressOnes <= deniedOnes && ingress.Contains(denied.IP)
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"net"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/coinbase/step-asg-deployer/aws/ami"
+	"github.com/coinbase/step-asg-deployer/aws/sg"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func sshPolicy() *DeployerPolicy {
+	return &DeployerPolicy{
+		SecurityGroups: &SecurityGroupPolicy{
+			DenyRules: []*DenyRule{
+				&DenyRule{CIDR: to.Strp("0.0.0.0/0"), Port: to.Int64p(22)},
+				&DenyRule{CIDR: to.Strp("0.0.0.0/0"), AllPorts: to.Boolp(true)},
+			},
+			DenyCrossAccount: to.Boolp(true),
+			AllowedAccounts:  []*string{to.Strp("222222222222")},
+		},
+	}
+}
+
+func Test_DeployerPolicy_Validate(t *testing.T) {
+	assert.NoError(t, (&DeployerPolicy{}).Validate())
+	assert.NoError(t, sshPolicy().Validate())
+
+	empty := &DeployerPolicy{SecurityGroups: &SecurityGroupPolicy{DenyRules: []*DenyRule{&DenyRule{AllPorts: to.Boolp(false)}}}}
+	assert.Error(t, empty.Validate())
+
+	invalid := &DeployerPolicy{SecurityGroups: &SecurityGroupPolicy{DenyRules: []*DenyRule{&DenyRule{CIDR: to.Strp("0.0.0.0")}}}}
+	assert.Error(t, invalid.Validate())
+}
+
+func Test_DeployerPolicy_ValidateSecurityGroup(t *testing.T) {
+	policy := sshPolicy()
+
+	ssh := &sg.SecurityGroup{GroupID: to.Strp("sg-1"), IngressRules: []*sg.IngressRule{
+		&sg.IngressRule{Protocol: to.Strp("tcp"), FromPort: to.Int64p(22), ToPort: to.Int64p(22), CIDRs: []*string{to.Strp("0.0.0.0/0")}},
+	}}
+	err := policy.ValidateSecurityGroup(to.Strp("account"), ssh)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tcp 22-22 from 0.0.0.0/0")
+
+	private := &sg.SecurityGroup{GroupID: to.Strp("sg-1"), IngressRules: []*sg.IngressRule{
+		&sg.IngressRule{Protocol: to.Strp("tcp"), FromPort: to.Int64p(22), ToPort: to.Int64p(22), CIDRs: []*string{to.Strp("10.0.0.0/8")}},
+		&sg.IngressRule{Protocol: to.Strp("tcp"), FromPort: to.Int64p(443), ToPort: to.Int64p(443), CIDRs: []*string{to.Strp("0.0.0.0/0")}},
+	}}
+	assert.NoError(t, policy.ValidateSecurityGroup(to.Strp("account"), private))
+
+	// Only ranges including the whole denied range are denied
+	for cidr, denied := range map[string]bool{
+		"1.2.3.4/0":     true,
+		"::/0":          true,
+		"0.0.0.0/1":     false,
+		"128.0.0.0/1":   false,
+		"2001:db8::/32": false,
+	} {
+		ingress := &sg.SecurityGroup{GroupID: to.Strp("sg-1"), IngressRules: []*sg.IngressRule{
+			&sg.IngressRule{Protocol: to.Strp("tcp"), FromPort: to.Int64p(22), ToPort: to.Int64p(22), CIDRs: []*string{to.Strp(cidr)}},
+		}}
+		assert.Equal(t, denied, policy.ValidateSecurityGroup(to.Strp("account"), ingress) != nil, cidr)
+	}
+
+	open := &sg.SecurityGroup{GroupID: to.Strp("sg-1"), IngressRules: []*sg.IngressRule{
+		&sg.IngressRule{Protocol: to.Strp("-1"), CIDRs: []*string{to.Strp("0.0.0.0/0")}},
+	}}
+	assert.Error(t, policy.ValidateSecurityGroup(to.Strp("account"), open))
+
+	// A nil or empty policy allows everything
+	var none *DeployerPolicy
+	assert.NoError(t, none.ValidateSecurityGroup(to.Strp("account"), open))
+	assert.NoError(t, (&DeployerPolicy{}).ValidateSecurityGroup(to.Strp("account"), open))
+}
+
+func Test_DeployerPolicy_ValidateSecurityGroup_CrossAccount(t *testing.T) {
+	policy := sshPolicy()
+
+	ref := func(owner string) *sg.SecurityGroup {
+		return &sg.SecurityGroup{GroupID: to.Strp("sg-1"), IngressRules: []*sg.IngressRule{
+			&sg.IngressRule{
+				Protocol:    to.Strp("tcp"),
+				FromPort:    to.Int64p(443),
+				ToPort:      to.Int64p(443),
+				GroupIDs:    []*string{to.Strp("sg-2")},
+				GroupOwners: []*string{to.Strp(owner)},
+			},
+		}}
+	}
+
+	assert.NoError(t, policy.ValidateSecurityGroup(to.Strp("account"), ref("account")))
+	assert.NoError(t, policy.ValidateSecurityGroup(to.Strp("account"), ref("222222222222")))
+	assert.Error(t, policy.ValidateSecurityGroup(to.Strp("account"), ref("333333333333")))
+
+	policy.SecurityGroups.DenyCrossAccount = nil
+	assert.NoError(t, policy.ValidateSecurityGroup(to.Strp("account"), ref("333333333333")))
+}
+
+func Test_Release_ValidateResources_DeployerPolicy(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+	awsc.EC2.AddSecurityGroupIngress("web-sg", &ec2.IpPermission{
+		IpProtocol: to.Strp("tcp"),
+		FromPort:   to.Int64p(22),
+		ToPort:     to.Int64p(22),
+		IpRanges:   []*ec2.IpRange{&ec2.IpRange{CidrIp: to.Strp("0.0.0.0/0")}},
+	})
+
+	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.ValidateResources(sm, &DeployerPolicy{}))
+	assert.Error(t, r.ValidateResources(sm, sshPolicy()))
+}
+
+func Test_DeployerPolicy_ValidateImage(t *testing.T) {
+	im := func() *ami.Image {
+		return &ami.Image{
+			ImageID:        to.Strp("ami-123456"),
+			OwnerID:        to.Strp("111111111111"),
+			ApprovedForTag: to.Strp("development,production"),
+			RootEncrypted:  true,
+		}
+	}
+
+	policy := &DeployerPolicy{Images: &ImagePolicy{
+		TrustedOwners:    []*string{to.Strp("111111111111")},
+		DenyDeprecated:   to.Boolp(true),
+		RequireEncrypted: to.Boolp(true),
+		RequireApproval:  to.Boolp(true),
+	}}
+
+	assert.NoError(t, policy.ValidateImage(to.Strp("production"), im()))
+	assert.Error(t, policy.ValidateImage(to.Strp("staging"), im()))
+
+	untrusted := im()
+	untrusted.OwnerID = to.Strp("222222222222")
+	assert.Error(t, policy.ValidateImage(to.Strp("production"), untrusted))
+
+	deprecated := im()
+	deprecated.DeprecationTime = to.Strp("2019-01-01T00:00:00.000Z")
+	assert.Error(t, policy.ValidateImage(to.Strp("production"), deprecated))
+
+	unencrypted := im()
+	unencrypted.RootEncrypted = false
+	assert.Error(t, policy.ValidateImage(to.Strp("production"), unencrypted))
+
+	// Without an image policy only the default checks apply
+	assert.NoError(t, (&DeployerPolicy{}).ValidateImage(to.Strp("staging"), unencrypted))
+}
+
+func Test_DeployerPolicy_ValidateService(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	service := r.Services["web"]
+
+	policy := &DeployerPolicy{Instances: &InstancePolicy{RequireIMDSv2: to.Boolp(true)}}
+	assert.Error(t, policy.ValidateService(service))
+
+	service.MetadataOptions = &MetadataOptions{HTTPTokens: to.Strp("optional")}
+	assert.Error(t, policy.ValidateService(service))
+
+	service.MetadataOptions = &MetadataOptions{HTTPTokens: to.Strp("required")}
+	assert.NoError(t, policy.ValidateService(service))
+
+	service.MetadataOptions = &MetadataOptions{HTTPEndpoint: to.Strp("disabled")}
+	assert.NoError(t, policy.ValidateService(service))
+
+	// A nil or empty policy allows everything
+	var none *DeployerPolicy
+	assert.NoError(t, none.ValidateService(&Service{}))
+	assert.NoError(t, (&DeployerPolicy{}).ValidateService(&Service{}))
+}
+
+func Test_includes(t *testing.T) {
+	_, private, _ := net.ParseCIDR("10.0.0.0/8")
+
+	assert.True(t, includes("10.0.0.0/8", private))
+	assert.True(t, includes("8.0.0.0/6", private))
+	assert.True(t, includes("0.0.0.0/0", private))
+	assert.False(t, includes("10.1.0.0/16", private))
+	assert.False(t, includes("11.0.0.0/8", private))
+	assert.False(t, includes("::/0", private))
+	assert.False(t, includes("not a cidr", private))
+
+	_, all, _ := net.ParseCIDR("0.0.0.0/0")
+
+	assert.True(t, includes("0.0.0.0/0", all))
+	assert.True(t, includes("::/0", all))
+	assert.False(t, includes("0.0.0.0/1", all))
+}
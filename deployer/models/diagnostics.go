@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/asg"
+	"github.com/coinbase/step-asg-deployer/aws/console"
+	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
+)
+
+// Diagnostics explain why a service is not healthy.
+// The instance states are recorded every time the service is checked, the
+// failed scaling activities and console output are added if the release fails.
+// The release is passed between states so these are limited to keep it small,
+// and the console output is written to the bucket with only its path in the release.
+
+const maxDiagnosedInstances = 10
+const maxFailedActivities = 10
+const maxConsoleOutputs = 3
+const maxConsoleOutputLines = 50
+
+// Diagnostics struct
+type Diagnostics struct {
+	Instances        aws.InstanceDetails `json:"instances,omitempty"` // Only instances that are not healthy
+	FailedActivities []*string           `json:"failed_activities,omitempty"`
+}
+
+// setDiagnostics records the details of the instances that are not healthy
+func (service *Service) setDiagnostics(instances aws.Instances, details aws.InstanceDetails) {
+	diagnostics := &Diagnostics{Instances: aws.InstanceDetails{}}
+
+	for _, id := range instances.NotHealthyIDs() {
+		if len(diagnostics.Instances) >= maxDiagnosedInstances {
+			break
+		}
+
+		if detail, ok := details[id]; ok {
+			diagnostics.Instances[id] = detail
+		}
+	}
+
+	service.Diagnostics = diagnostics
+}
+
+// Diagnose adds the failed scaling activities and the console output of unhealthy instances
+func (service *Service) Diagnose(asgc aws.ASGAPI, ec2c aws.EC2API, s3c aws.S3API) error {
+	if service.CreatedASG == nil {
+		return nil // Never created
+	}
+
+	if service.Diagnostics == nil {
+		service.Diagnostics = &Diagnostics{}
+	}
+
+	activities, err := asg.FailedActivities(asgc, service.CreatedASG, maxFailedActivities)
+	if err != nil {
+		return err
+	}
+
+	service.Diagnostics.FailedActivities = []*string{}
+	for _, activity := range activities {
+		message := activity.StatusMessage
+		if message == nil {
+			message = activity.Description
+		}
+
+		service.Diagnostics.FailedActivities = append(service.Diagnostics.FailedActivities,
+			to.Strp(fmt.Sprintf("%v: %v", to.Strs(activity.StatusCode), to.Strs(message))))
+	}
+
+	if service.ConsoleOutputLines == nil || *service.ConsoleOutputLines == 0 {
+		return nil
+	}
+
+	for i, id := range service.diagnosedIDs() {
+		if i >= maxConsoleOutputs {
+			break
+		}
+
+		tail, err := console.Tail(ec2c, to.Strp(id), *service.ConsoleOutputLines)
+		if err != nil {
+			return err
+		}
+
+		if tail == nil {
+			continue // Not available yet
+		}
+
+		path := service.release.ConsoleOutputPath(*service.ServiceName, id)
+		if err := s3.Put(s3c, service.release.Bucket, path, tail); err != nil {
+			return err
+		}
+
+		service.Diagnostics.Instances[id].ConsoleOutput = path
+	}
+
+	return nil
+}
+
+func (service *Service) diagnosedIDs() []string {
+	ids := []string{}
+	for id := range service.Diagnostics.Instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
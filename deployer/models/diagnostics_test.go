@@ -0,0 +1,108 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_setDiagnostics(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	service := r.Services["web"]
+
+	instances := aws.Instances{}
+	details := aws.InstanceDetails{}
+	for _, i := range []*autoscaling.Instance{
+		&autoscaling.Instance{InstanceId: to.Strp("i-1"), HealthStatus: to.Strp("Healthy"), LifecycleState: to.Strp("InService")},
+		&autoscaling.Instance{InstanceId: to.Strp("i-2"), HealthStatus: to.Strp("Healthy"), LifecycleState: to.Strp("InService")},
+	} {
+		instances.AddASGInstance(i)
+		details.AddASGInstance(i)
+	}
+
+	thd := &elbv2.TargetHealthDescription{
+		Target: &elbv2.TargetDescription{Id: to.Strp("i-2")},
+		TargetHealth: &elbv2.TargetHealth{
+			State:       to.Strp("unhealthy"),
+			Reason:      to.Strp("Target.ResponseCodeMismatch"),
+			Description: to.Strp("Health checks failed with these codes: [502]"),
+		},
+	}
+	tgInstances := aws.Instances{"i-1": "healthy"}
+	tgInstances.AddTargetGroupInstance(thd)
+	details.AddTargetGroupInstance("web-tg", thd)
+	instances = instances.MergeInstances(tgInstances)
+
+	service.setDiagnostics(instances, details)
+
+	// Only the instances that are not healthy are kept
+	assert.Equal(t, 1, len(service.Diagnostics.Instances))
+	detail := service.Diagnostics.Instances["i-2"]
+	assert.Equal(t, "InService", *detail.LifecycleState)
+	assert.Equal(t, "Target.ResponseCodeMismatch", *detail.Targets["web-tg"].ReasonCode)
+}
+
+func Test_Service_Diagnose(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	awsc := MockAwsClients(r)
+	service := r.Services["web"]
+
+	// Nothing to diagnose if the ASG was never created
+	assert.NoError(t, service.Diagnose(awsc.ASG, awsc.EC2, awsc.S3))
+	assert.Nil(t, service.Diagnostics)
+
+	service.CreatedASG = service.ServiceID
+	service.Diagnostics = &Diagnostics{Instances: aws.InstanceDetails{"i-2": &aws.InstanceDetail{}}}
+
+	awsc.ASG.AddActivity("Successful", "Launched")
+	awsc.ASG.AddActivity("Failed", "Instance failed to complete user's Lifecycle Action")
+	awsc.EC2.AddConsoleOutput("i-2", "boot\ncache warming failed")
+
+	assert.NoError(t, service.Diagnose(awsc.ASG, awsc.EC2, awsc.S3))
+	assert.Equal(t, []string{"Failed: Instance failed to complete user's Lifecycle Action"}, to.StrSlice(service.Diagnostics.FailedActivities))
+	assert.Nil(t, service.Diagnostics.Instances["i-2"].ConsoleOutput) // Not enabled
+
+	service.ConsoleOutputLines = to.Intp(1)
+	assert.NoError(t, service.Diagnose(awsc.ASG, awsc.EC2, awsc.S3))
+
+	// Only the path of the output is kept in the release
+	path := service.Diagnostics.Instances["i-2"].ConsoleOutput
+	assert.Equal(t, *r.ConsoleOutputPath("web", "i-2"), *path)
+	body, err := ioutil.ReadAll(awsc.S3.GetObjectResp[*path].Resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "cache warming failed", string(body))
+}
+
+func Test_Release_Diagnose_ContinuesPastErrors(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	awsc := MockAwsClients(r)
+
+	service := r.Services["web"]
+	service.CreatedASG = service.ServiceID
+	service.ConsoleOutputLines = to.Intp(1)
+	service.Diagnostics = &Diagnostics{Instances: aws.InstanceDetails{"i-2": &aws.InstanceDetail{}}}
+
+	// A service that cannot be diagnosed is before web
+	api := &Service{}
+	api.SetDefaults(r, "api")
+	api.CreatedASG = api.ServiceID
+	r.Services["api"] = api
+
+	awsc.ASG.DescribeScalingActivitiesErrors = map[string]error{*api.CreatedASG: fmt.Errorf("Throttling")}
+	awsc.ASG.AddActivity("Failed", "Instance failed to complete user's Lifecycle Action")
+	awsc.EC2.AddConsoleOutput("i-2", "cache warming failed")
+
+	err := r.Diagnose(awsc.ASG, awsc.EC2, awsc.S3)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "api: Throttling")
+	assert.NotNil(t, service.Diagnostics.Instances["i-2"].ConsoleOutput)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is an error with the JSON path of the field that caused it
+// e.g. services.web.autoscaling.spread
+type FieldError struct {
+	Path string
+	Err  string
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Err
+	}
+	return fmt.Sprintf("%v: %v", e.Path, e.Err)
+}
+
+// fieldErrorf returns a FieldError for the path
+func fieldErrorf(path string, format string, a ...interface{}) error {
+	return &FieldError{Path: path, Err: fmt.Sprintf(format, a...)}
+}
+
+// inPath prefixes the path of err, errors without a path are given one
+func inPath(prefix string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	fe, ok := err.(*FieldError)
+	if !ok {
+		return &FieldError{Path: prefix, Err: err.Error()}
+	}
+
+	return &FieldError{Path: joinPath(prefix, fe.Path), Err: fe.Err}
+}
+
+func joinPath(prefix string, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	case strings.HasPrefix(path, "["):
+		return prefix + path
+	}
+	return fmt.Sprintf("%v.%v", prefix, path)
+}
+
+// indexPath returns the path of an element in a list e.g. policies[0]
+func indexPath(path string, i int) string {
+	return fmt.Sprintf("%v[%v]", path, i)
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// The identity of a deployer is the name of its Lambda and Step Function,
+// its bucket and the role it assumes in each account.
+// Independent deployers, e.g. production and non-production, can run
+// in the same accounts with different identities.
+
+// DefaultDeployerName is the name of the Lambda and Step Function if not configured
+const DefaultDeployerName = "coinbase-step-asg-deployer"
+
+// Environment variables the Lambda and client read the identity from
+const (
+	DeployerNameEnv        = "STEP_ASG_DEPLOYER_NAME"
+	DeployerBucketEnv      = "STEP_ASG_DEPLOYER_BUCKET"
+	DeployerAssumedRoleEnv = "STEP_ASG_DEPLOYER_ASSUMED_ROLE"
+)
+
+// Identity struct
+type Identity struct {
+	Name        *string `json:"name,omitempty"`         // Lambda and Step Function name
+	Bucket      *string `json:"bucket,omitempty"`       // Defaults to <name>-<account_id>
+	AssumedRole *string `json:"assumed_role,omitempty"` // Defaults to <name>-assumed
+}
+
+// DefaultIdentity returns the identity of the default deployer
+func DefaultIdentity() *Identity {
+	identity := &Identity{}
+	identity.SetDefaults()
+	return identity
+}
+
+// IdentityFromEnv returns the identity from the environment, unset values are nil
+func IdentityFromEnv() *Identity {
+	return &Identity{
+		Name:        envStrp(DeployerNameEnv),
+		Bucket:      envStrp(DeployerBucketEnv),
+		AssumedRole: envStrp(DeployerAssumedRoleEnv),
+	}
+}
+
+// LoadIdentity returns the identity in a JSON file, values not in the file are taken from base
+func LoadIdentity(file string, base *Identity) (*Identity, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var identity Identity
+	if err := json.Unmarshal(raw, &identity); err != nil {
+		return nil, fmt.Errorf("Error parsing identity %v: %v", file, err.Error())
+	}
+
+	return identity.Merge(base), nil
+}
+
+// Merge returns the identity with values it does not set taken from base
+func (identity *Identity) Merge(base *Identity) *Identity {
+	merged := *identity
+	if base == nil {
+		return &merged
+	}
+
+	if is.EmptyStr(merged.Name) {
+		merged.Name = base.Name
+	}
+
+	if is.EmptyStr(merged.Bucket) {
+		merged.Bucket = base.Bucket
+	}
+
+	if is.EmptyStr(merged.AssumedRole) {
+		merged.AssumedRole = base.AssumedRole
+	}
+
+	return &merged
+}
+
+// SetDefaults assigns default values
+func (identity *Identity) SetDefaults() {
+	if is.EmptyStr(identity.Name) {
+		identity.Name = to.Strp(DefaultDeployerName)
+	}
+
+	if is.EmptyStr(identity.AssumedRole) {
+		identity.AssumedRole = to.Strp(fmt.Sprintf("%v-assumed", *identity.Name))
+	}
+}
+
+// BucketName returns the bucket of the deployer in the account
+func (identity *Identity) BucketName(accountID *string) *string {
+	if !is.EmptyStr(identity.Bucket) {
+		return identity.Bucket
+	}
+
+	if accountID == nil {
+		return nil
+	}
+
+	return to.Strp(fmt.Sprintf("%v-%v", *identity.Name, *accountID))
+}
+
+// StateMachineARN returns the ARN of the deployers Step Function
+func (identity *Identity) StateMachineARN(region *string, accountID *string) *string {
+	return to.StepArn(region, accountID, identity.Name)
+}
+
+func envStrp(name string) *string {
+	value := os.Getenv(name)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Identity_Defaults(t *testing.T) {
+	identity := DefaultIdentity()
+	assert.Equal(t, "coinbase-step-asg-deployer", *identity.Name)
+	assert.Equal(t, "coinbase-step-asg-deployer-assumed", *identity.AssumedRole)
+	assert.Equal(t, "coinbase-step-asg-deployer-account", *identity.BucketName(to.Strp("account")))
+	assert.Nil(t, identity.BucketName(nil))
+	assert.Equal(t, *to.StepArn(to.Strp("region"), to.Strp("account"), to.Strp("coinbase-step-asg-deployer")), *identity.StateMachineARN(to.Strp("region"), to.Strp("account")))
+
+	identity = &Identity{Name: to.Strp("non-prod-deployer")}
+	identity.SetDefaults()
+	assert.Equal(t, "non-prod-deployer-assumed", *identity.AssumedRole)
+	assert.Equal(t, "non-prod-deployer-account", *identity.BucketName(to.Strp("account")))
+
+	identity.Bucket = to.Strp("bucket")
+	assert.Equal(t, "bucket", *identity.BucketName(to.Strp("account")))
+}
+
+func Test_Identity_FromEnv(t *testing.T) {
+	os.Setenv(DeployerNameEnv, "env-deployer")
+	defer os.Unsetenv(DeployerNameEnv)
+
+	identity := IdentityFromEnv()
+	assert.Equal(t, "env-deployer", *identity.Name)
+	assert.Nil(t, identity.Bucket)
+	assert.Nil(t, identity.AssumedRole)
+}
+
+func Test_LoadIdentity(t *testing.T) {
+	file, err := ioutil.TempFile("", "identity")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString(`{"bucket": "file-bucket"}`)
+	assert.NoError(t, err)
+	file.Close()
+
+	identity, err := LoadIdentity(file.Name(), &Identity{Name: to.Strp("base"), Bucket: to.Strp("base-bucket")})
+	assert.NoError(t, err)
+	assert.Equal(t, "base", *identity.Name)
+	assert.Equal(t, "file-bucket", *identity.Bucket)
+
+	_, err = LoadIdentity("/does/not/exist.json", nil)
+	assert.Error(t, err)
+}
+
+func Test_Release_SetDefaultBucket(t *testing.T) {
+	r := MockMinimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+
+	r.SetDefaultBucket(&Identity{Name: to.Strp("non-prod-deployer")}, to.Strp("account"))
+	assert.Equal(t, "non-prod-deployer-account", *r.Bucket)
+
+	// Bucket in the release is kept
+	r.SetDefaultBucket(DefaultIdentity(), to.Strp("account"))
+	assert.Equal(t, "non-prod-deployer-account", *r.Bucket)
+
+	// A release in another account uses the deployers bucket
+	other := MockMinimalRelease(t)
+	other.AwsAccountID = to.Strp("other")
+	other.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	other.SetDefaultBucket(DefaultIdentity(), to.Strp("account"))
+	assert.Equal(t, "coinbase-step-asg-deployer-account", *other.Bucket)
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step/utils/to"
+)
+
+// LaunchReadinessHookName is the launching lifecycle hook an instance completes when it is ready
+const LaunchReadinessHookName = "launch-readiness"
+
+// LaunchReadiness adds a launching lifecycle hook to the service's ASG.
+// Instances stay in Pending:Wait (and are not healthy) until they call
+// CompleteLifecycleAction with CONTINUE, or are terminated after the timeout.
+type LaunchReadiness struct {
+	Timeout *int64 `json:"timeout,omitempty"` // seconds
+}
+
+// SetDefaults assigns default values
+func (lr *LaunchReadiness) SetDefaults() {
+	if lr.Timeout == nil {
+		lr.Timeout = to.Int64p(600)
+	}
+}
+
+// ValidateAttributes validates attributes
+func (lr *LaunchReadiness) ValidateAttributes() error {
+	if lr.Timeout == nil || *lr.Timeout < 30 || *lr.Timeout > 7200 {
+		return fieldErrorf("timeout", "Timeout must be between 30 and 7200 seconds")
+	}
+
+	return nil
+}
+
+// ToLifecycleHookSpecification returns Specification
+func (lr *LaunchReadiness) ToLifecycleHookSpecification() *autoscaling.LifecycleHookSpecification {
+	return &autoscaling.LifecycleHookSpecification{
+		LifecycleHookName:   to.Strp(LaunchReadinessHookName),
+		LifecycleTransition: to.Strp(launchingTransition),
+		HeartbeatTimeout:    lr.Timeout,
+		DefaultResult:       to.Strp("ABANDON"), // Instances that never become ready are terminated
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LaunchReadiness_Hook(t *testing.T) {
+	r := MockRelease(t)
+	r.Services["web"].LaunchReadiness = &LaunchReadiness{}
+	MockPrepareRelease(r)
+	service := r.Services["web"]
+
+	assert.NoError(t, service.ValidateAttributes())
+	assert.Equal(t, int64(600), *service.LaunchReadiness.Timeout)
+
+	specs := service.LifeCycleHookSpecs()
+	assert.Equal(t, 2, len(specs))
+
+	hook := specs[1]
+	assert.Equal(t, LaunchReadinessHookName, *hook.LifecycleHookName)
+	assert.Equal(t, launchingTransition, *hook.LifecycleTransition)
+	assert.Equal(t, "ABANDON", *hook.DefaultResult)
+	assert.Nil(t, hook.NotificationTargetARN)
+	assert.NoError(t, hook.Validate())
+
+	service.LaunchReadiness.Timeout = to.Int64p(10)
+	assert.Equal(t, "launch_readiness.timeout", service.ValidateAttributes().(*FieldError).Path)
+}
+
+func Test_LaunchReadiness_ReservedName(t *testing.T) {
+	r := MockRelease(t)
+	r.LifeCycleHooks[LaunchReadinessHookName] = r.LifeCycleHooks["TermHook"]
+	MockPrepareRelease(r)
+
+	err := r.ValidateServices()
+	assert.Error(t, err)
+	assert.Equal(t, "lifecycle.launch-readiness", err.(*FieldError).Path)
+}
+
+func Test_LaunchReadiness_setHealthy(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	service := r.Services["web"]
+
+	// Instances waiting for the hook are not healthy
+	service.setHealthy(aws.Instances{"i-1": "pending-hook", "i-2": "healthy"})
+	assert.Equal(t, 1, *service.HealthReport.Healthy)
+	assert.Equal(t, 1, *service.HealthReport.PendingHook)
+	assert.Equal(t, 2, *service.HealthReport.Launching)
+
+	service.setHealthy(aws.Instances{"i-1": "healthy", "i-2": "healthy"})
+	assert.Nil(t, service.HealthReport.PendingHook)
+}
@@ -7,16 +7,27 @@ import (
 	"github.com/coinbase/step-asg-deployer/aws"
 	"github.com/coinbase/step-asg-deployer/aws/iam"
 	"github.com/coinbase/step-asg-deployer/aws/sns"
+	"github.com/coinbase/step-asg-deployer/aws/sqs"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
 )
 
+const launchingTransition = "autoscaling:EC2_INSTANCE_LAUNCHING"
+const terminatingTransition = "autoscaling:EC2_INSTANCE_TERMINATING"
+
+var lifecycleDefaultResults = []string{"CONTINUE", "ABANDON"}
+
 // LifeCycleHook struct
+// The hook is sent to the SNS topic or SQS queue, or if neither is set
+// only to EventBridge (CloudWatch Events)
 type LifeCycleHook struct {
-	Transistion      *string `json:"transition,omitempty"`
-	SNS              *string `json:"sns,omitempty"`
-	Role             *string `json:"role,omitempty"`
-	HeartbeatTimeout *int64  `json:"heartbeat_timeout,omitempty"`
+	Transistion          *string `json:"transition,omitempty"`
+	SNS                  *string `json:"sns,omitempty"`
+	SQS                  *string `json:"sqs,omitempty"`
+	Role                 *string `json:"role,omitempty"`
+	HeartbeatTimeout     *int64  `json:"heartbeat_timeout,omitempty"`
+	DefaultResult        *string `json:"default_result,omitempty"` // AWS defaults to ABANDON
+	NotificationMetadata *string `json:"notification_metadata,omitempty"`
 
 	RoleARN               *string `json:"role_arn,omitempty"`
 	NotificationTargetARN *string `json:"notification_target_arn,omitempty"`
@@ -31,16 +42,20 @@ func (lc *LifeCycleHook) ToLifecycleHookSpecification() *autoscaling.LifecycleHo
 
 		LifecycleTransition: lc.Transistion,
 
+		DefaultResult:        lc.DefaultResult,
+		NotificationMetadata: lc.NotificationMetadata,
+
 		NotificationTargetARN: lc.NotificationTargetARN,
 		RoleARN:               lc.RoleARN,
 	}
 }
 
 // FetchResources validates resources exist
-func (lc *LifeCycleHook) FetchResources(iamc aws.IAMAPI, snsc aws.SNSAPI) error {
-	err := iam.RoleExists(iamc, lc.Role)
-	if err != nil {
-		return err
+func (lc *LifeCycleHook) FetchResources(iamc aws.IAMAPI, snsc aws.SNSAPI, sqsc aws.SQSAPI) error {
+	if lc.Role != nil {
+		if err := iam.RoleExists(iamc, lc.Role); err != nil {
+			return err
+		}
 	}
 
 	if lc.SNS != nil {
@@ -49,6 +64,12 @@ func (lc *LifeCycleHook) FetchResources(iamc aws.IAMAPI, snsc aws.SNSAPI) error
 		}
 	}
 
+	if lc.SQS != nil {
+		if err := sqs.QueueExists(sqsc, lc.SQS); err != nil {
+			return fmt.Errorf("SQS queue does not exist %v", err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -63,6 +84,10 @@ func (lc *LifeCycleHook) SetDefaults(region *string, accountID *string, name str
 	if lc.SNS != nil && lc.NotificationTargetARN == nil {
 		lc.NotificationTargetARN = to.Strp(fmt.Sprintf("arn:aws:sns:%v:%v:%v", *region, *accountID, *lc.SNS))
 	}
+
+	if lc.SQS != nil && lc.NotificationTargetARN == nil {
+		lc.NotificationTargetARN = to.Strp(fmt.Sprintf("arn:aws:sqs:%v:%v:%v", *region, *accountID, *lc.SQS))
+	}
 }
 
 // ValidateAttributes validates attributes
@@ -72,16 +97,28 @@ func (lc *LifeCycleHook) ValidateAttributes() error {
 		return err
 	}
 
-	if is.EmptyStr(lc.RoleARN) {
-		return fmt.Errorf("Lifecycle RoleARN nil")
+	if lc.SNS != nil && lc.SQS != nil {
+		return fieldErrorf("sqs", "Lifecycle cannot have both sns and sqs")
+	}
+
+	if !is.EmptyStr(lc.NotificationTargetARN) && is.EmptyStr(lc.RoleARN) {
+		return fieldErrorf("role", "Lifecycle RoleARN nil")
+	}
+
+	if is.EmptyStr(lc.NotificationTargetARN) && lc.RoleARN != nil {
+		return fieldErrorf("role", "Lifecycle role requires sns or sqs")
+	}
+
+	if *lc.Transistion != launchingTransition && *lc.Transistion != terminatingTransition {
+		return fieldErrorf("transition", "Transistion must equal either '%v' or '%v'", launchingTransition, terminatingTransition)
 	}
 
-	if is.EmptyStr(lc.NotificationTargetARN) {
-		return fmt.Errorf("Lifecycle NotificationTargetARN nil")
+	if lc.DefaultResult != nil && !inStrs(lifecycleDefaultResults, *lc.DefaultResult) {
+		return fieldErrorf("default_result", "DefaultResult must be one of %v", lifecycleDefaultResults)
 	}
 
-	if *lc.Transistion != "autoscaling:EC2_INSTANCE_LAUNCHING" && *lc.Transistion != "autoscaling:EC2_INSTANCE_TERMINATING" {
-		return fmt.Errorf("Transistion must equal either 'autoscaling:EC2_INSTANCE_LAUNCHING' or 'autoscaling:EC2_INSTANCE_TERMINATING'")
+	if lc.NotificationMetadata != nil && len(*lc.NotificationMetadata) > 1023 {
+		return fieldErrorf("notification_metadata", "NotificationMetadata must be at most 1023 characters")
 	}
 
 	return nil
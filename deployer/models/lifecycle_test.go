@@ -3,6 +3,7 @@ package models
 import (
 	"testing"
 
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
@@ -17,3 +18,74 @@ func Test_Lifecycle_Valid(t *testing.T) {
 	lc.SetDefaults(to.Strp("region"), to.Strp("accountID"), "name")
 	assert.NoError(t, lc.ValidateAttributes())
 }
+
+func Test_Lifecycle_SQS(t *testing.T) {
+	lc := &LifeCycleHook{
+		Transistion:          to.Strp("autoscaling:EC2_INSTANCE_TERMINATING"),
+		Role:                 to.Strp("role"),
+		SQS:                  to.Strp("queue"),
+		DefaultResult:        to.Strp("CONTINUE"),
+		NotificationMetadata: to.Strp(`{"service":"worker"}`),
+	}
+
+	lc.SetDefaults(to.Strp("region"), to.Strp("accountID"), "name")
+	assert.NoError(t, lc.ValidateAttributes())
+	assert.Equal(t, "arn:aws:sqs:region:accountID:queue", *lc.NotificationTargetARN)
+
+	spec := lc.ToLifecycleHookSpecification()
+	assert.Equal(t, "CONTINUE", *spec.DefaultResult)
+	assert.Equal(t, `{"service":"worker"}`, *spec.NotificationMetadata)
+
+	lc.SNS = to.Strp("sns")
+	assert.Equal(t, "sqs", lc.ValidateAttributes().(*FieldError).Path)
+	lc.SNS = nil
+
+	lc.DefaultResult = to.Strp("RETRY")
+	assert.Equal(t, "default_result", lc.ValidateAttributes().(*FieldError).Path)
+}
+
+func Test_Lifecycle_NoTarget(t *testing.T) {
+	// Hooks without a target are only sent to EventBridge
+	lc := &LifeCycleHook{
+		Transistion: to.Strp("autoscaling:EC2_INSTANCE_LAUNCHING"),
+	}
+
+	lc.SetDefaults(to.Strp("region"), to.Strp("accountID"), "name")
+	assert.NoError(t, lc.ValidateAttributes())
+	assert.Nil(t, lc.NotificationTargetARN)
+	assert.Nil(t, lc.RoleARN)
+
+	// A role without a target is a mistake
+	lc.Role = to.Strp("role")
+	lc.SetDefaults(to.Strp("region"), to.Strp("accountID"), "name")
+	assert.Equal(t, "role", lc.ValidateAttributes().(*FieldError).Path)
+
+	// A target needs a role
+	lc = &LifeCycleHook{
+		Transistion: to.Strp("autoscaling:EC2_INSTANCE_LAUNCHING"),
+		SNS:         to.Strp("sns"),
+	}
+	lc.SetDefaults(to.Strp("region"), to.Strp("accountID"), "name")
+	assert.Equal(t, "role", lc.ValidateAttributes().(*FieldError).Path)
+}
+
+func Test_Lifecycle_FetchResources(t *testing.T) {
+	awsc := mocks.MockAWS()
+	awsc.IAM.AddGetRole("role")
+
+	lc := &LifeCycleHook{
+		Transistion: to.Strp("autoscaling:EC2_INSTANCE_TERMINATING"),
+		Role:        to.Strp("role"),
+		SQS:         to.Strp("queue"),
+	}
+	lc.SetDefaults(to.Strp("region"), to.Strp("accountID"), "name")
+
+	assert.Error(t, lc.FetchResources(awsc.IAM, awsc.SNS, awsc.SQS))
+
+	awsc.SQS.AddQueue("queue")
+	assert.NoError(t, lc.FetchResources(awsc.IAM, awsc.SNS, awsc.SQS))
+
+	// No target needs no resources
+	lc = &LifeCycleHook{Transistion: to.Strp("autoscaling:EC2_INSTANCE_TERMINATING")}
+	assert.NoError(t, lc.FetchResources(awsc.IAM, awsc.SNS, awsc.SQS))
+}
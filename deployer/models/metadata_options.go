@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/coinbase/step/utils/to"
+)
+
+var httpTokens = []string{"optional", "required"}
+var httpEndpoints = []string{"enabled", "disabled"}
+var placementTenancies = []string{"default", "dedicated"}
+
+// MetadataOptions configures the instance metadata service (IMDS)
+type MetadataOptions struct {
+	HTTPTokens              *string `json:"http_tokens,omitempty"`                 // "required" enforces IMDSv2
+	HTTPPutResponseHopLimit *int64  `json:"http_put_response_hop_limit,omitempty"` // 1 to 64
+	HTTPEndpoint            *string `json:"http_endpoint,omitempty"`               // "disabled" turns off IMDS
+}
+
+// ToInstanceMetadataOptions returns the launch configuration metadata options
+func (mo *MetadataOptions) ToInstanceMetadataOptions() *autoscaling.InstanceMetadataOptions {
+	if mo == nil {
+		return nil
+	}
+
+	return &autoscaling.InstanceMetadataOptions{
+		HttpTokens:              mo.HTTPTokens,
+		HttpPutResponseHopLimit: mo.HTTPPutResponseHopLimit,
+		HttpEndpoint:            mo.HTTPEndpoint,
+	}
+}
+
+// ValidateAttributes validates attributes
+func (mo *MetadataOptions) ValidateAttributes() error {
+	if mo.HTTPTokens != nil && !inStrs(httpTokens, *mo.HTTPTokens) {
+		return fieldErrorf("http_tokens", "HTTPTokens %q must be one of %v", *mo.HTTPTokens, httpTokens)
+	}
+
+	if mo.HTTPEndpoint != nil && !inStrs(httpEndpoints, *mo.HTTPEndpoint) {
+		return fieldErrorf("http_endpoint", "HTTPEndpoint %q must be one of %v", *mo.HTTPEndpoint, httpEndpoints)
+	}
+
+	if mo.HTTPPutResponseHopLimit != nil && (*mo.HTTPPutResponseHopLimit < 1 || *mo.HTTPPutResponseHopLimit > 64) {
+		return fieldErrorf("http_put_response_hop_limit", "HTTPPutResponseHopLimit must be between 1 and 64")
+	}
+
+	return nil
+}
+
+// requiresIMDSv2 is true if instances cannot use IMDSv1
+func (mo *MetadataOptions) requiresIMDSv2() bool {
+	if mo == nil {
+		return false
+	}
+
+	if to.Strs(mo.HTTPEndpoint) == "disabled" {
+		return true // No IMDS at all
+	}
+
+	return to.Strs(mo.HTTPTokens) == "required"
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MetadataOptions_ValidateAttributes(t *testing.T) {
+	mo := &MetadataOptions{HTTPTokens: to.Strp("required"), HTTPPutResponseHopLimit: to.Int64p(2), HTTPEndpoint: to.Strp("enabled")}
+	assert.NoError(t, mo.ValidateAttributes())
+	assert.True(t, mo.requiresIMDSv2())
+
+	mo.HTTPTokens = to.Strp("v2")
+	assert.Equal(t, "http_tokens", mo.ValidateAttributes().(*FieldError).Path)
+	mo.HTTPTokens = to.Strp("optional")
+	assert.False(t, mo.requiresIMDSv2())
+
+	mo.HTTPEndpoint = to.Strp("off")
+	assert.Equal(t, "http_endpoint", mo.ValidateAttributes().(*FieldError).Path)
+	mo.HTTPEndpoint = to.Strp("disabled")
+	assert.True(t, mo.requiresIMDSv2())
+
+	mo.HTTPPutResponseHopLimit = to.Int64p(65)
+	assert.Equal(t, "http_put_response_hop_limit", mo.ValidateAttributes().(*FieldError).Path)
+
+	var none *MetadataOptions
+	assert.False(t, none.requiresIMDSv2())
+	assert.Nil(t, none.ToInstanceMetadataOptions())
+}
+
+func Test_Service_InstanceOptions(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	service := r.Services["web"]
+
+	// Defaults
+	input := service.createLaunchConfigurationInput()
+	assert.Nil(t, input.MetadataOptions)
+	assert.Nil(t, input.PlacementTenancy)
+	assert.False(t, *input.InstanceMonitoring.Enabled)
+
+	service.MetadataOptions = &MetadataOptions{HTTPTokens: to.Strp("required"), HTTPPutResponseHopLimit: to.Int64p(1)}
+	service.DetailedMonitoring = to.Boolp(true)
+	service.PlacementTenancy = to.Strp("dedicated")
+	assert.NoError(t, service.ValidateAttributes())
+
+	input = service.createLaunchConfigurationInput()
+	assert.Equal(t, "required", *input.MetadataOptions.HttpTokens)
+	assert.Equal(t, int64(1), *input.MetadataOptions.HttpPutResponseHopLimit)
+	assert.Nil(t, input.MetadataOptions.HttpEndpoint)
+	assert.Equal(t, "dedicated", *input.PlacementTenancy)
+	assert.True(t, *input.InstanceMonitoring.Enabled)
+
+	service.PlacementTenancy = to.Strp("host")
+	assert.Equal(t, "placement_tenancy", service.ValidateAttributes().(*FieldError).Path)
+	service.PlacementTenancy = nil
+
+	service.MetadataOptions.HTTPTokens = to.Strp("v2")
+	assert.Equal(t, "metadata_options.http_tokens", service.ValidateAttributes().(*FieldError).Path)
+}
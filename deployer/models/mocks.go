@@ -18,6 +18,7 @@ import (
 // MockPrepareRelease mocks
 func MockPrepareRelease(release *Release) {
 	release.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	release.SetDefaultBucket(DefaultIdentity(), to.Strp("account"))
 	release.SetDefaults()
 	release.SetUUID()
 }
@@ -31,6 +32,7 @@ func MockAwsClients(release *Release) *mocks.MockClients {
 
 		awsc.EC2.AddSecurityGroup("web-sg", *release.ProjectName, *release.ConfigName, "web", nil)
 		awsc.EC2.AddImage("ubuntu", "ami-123456")
+		awsc.EC2.AddInstanceType("t2.small", "i386", "x86_64")
 		awsc.EC2.AddSubnet("private-subnet", "subnet-1")
 
 		awsc.ELB.AddELB("web-elb", *release.ProjectName, *release.ConfigName, "web")
@@ -39,6 +41,9 @@ func MockAwsClients(release *Release) *mocks.MockClients {
 		awsc.IAM.AddGetInstanceProfile("web-profile", fmt.Sprintf("/%v/%v/web/", *release.ProjectName, *release.ConfigName))
 		awsc.IAM.AddGetRole("sns_role")
 
+		awsc.SSM.AddParameter(fmt.Sprintf("/%v/%v/web/db_password", *release.ProjectName, *release.ConfigName))
+		awsc.SM.AddSecret(fmt.Sprintf("/%v/%v/web/api_key", *release.ProjectName, *release.ConfigName))
+
 		if release.ReleaseID != nil {
 			raw, _ := json.Marshal(release)
 			awsc.S3.AddGetObject(*release.ReleasePath(), string(raw), nil)
@@ -79,7 +84,7 @@ func MockMinimalRelease(t *testing.T) *Release {
 }
 
 // MockRelease mocks
-func MockRelease(t *testing.T) *Release {
+func MockRelease(t testing.TB) *Release {
 	var r Release
 	err := json.Unmarshal([]byte(`
   {
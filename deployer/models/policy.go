@@ -109,11 +109,11 @@ func (a *Policy) Create(asgc aws.ASGAPI, cwc aws.CWAPI, asgName *string) error {
 // ValidateAttributes validates attributes
 func (a *Policy) ValidateAttributes() error {
 	if a.Type == nil {
-		return fmt.Errorf("Policy(?): Type nil")
+		return fieldErrorf("type", "Policy(?): Type nil")
 	}
 
 	if *a.Type != cpuScaleDown && *a.Type != cpuScaleUp {
-		return fmt.Errorf("Policy(%v): Unsupported Type %v", *a.Name(), *a.Type)
+		return fieldErrorf("type", "Policy(%v): Unsupported Type %v", *a.Name(), *a.Type)
 	}
 
 	if err := a.createMetricAlarmInput(to.Strp("asgName"), nil).Validate(); err != nil {
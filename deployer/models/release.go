@@ -2,9 +2,11 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/ami"
 	"github.com/coinbase/step/aws/s3"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
@@ -37,7 +39,7 @@ type Release struct {
 
 	Success *bool `json:"success,omitempty"`
 
-	Image *string `json:"ami,omitempty"`
+	Image *ami.Selector `json:"ami,omitempty"`
 
 	UserData *string `json:"user_data,omitempty"`
 
@@ -47,19 +49,54 @@ type Release struct {
 	// Maintain a Log to look at what has happened
 	Healthy *bool `json:"healthy,omitempty"`
 
+	// NextStage is true when the created services are healthy and services depending on them can be created
+	NextStage *bool `json:"next_stage,omitempty"`
+
+	// Paused is true while a pause file holds the release in WaitForHealthy
+	Paused *bool `json:"paused,omitempty"`
+
+	// PausedAt is when the current pause was first seen,
+	// PausedSeconds is how long previous pauses lasted, both extend the timeout
+	PausedAt      *time.Time `json:"paused_at,omitempty"`
+	PausedSeconds *int       `json:"paused_seconds,omitempty"`
+
+	// MaxPause is how long in seconds the release can be paused before it is halted
+	MaxPause *int `json:"max_pause,omitempty"`
+
+	// AwsRetries is the number of AWS calls retried while deploying the release
+	AwsRetries *int64 `json:"aws_retries,omitempty"`
+
 	// Where the previous Catch Error should be located
 	Error *ReleaseError `json:"error,omitempty"`
 
 	// AWS Service is Downloaded
 	Services map[string]*Service `json:"services,omitempty"` // Downloaded From S3
+
+	// OnlyServices are the services to deploy, the other services ASGs are left untouched
+	OnlyServices []*string `json:"only_services,omitempty"`
+
+	// Targets are the accounts and regions the client deploys the release to
+	Targets          []*Target `json:"targets,omitempty"`
+	TargetsParallel  *bool     `json:"targets_parallel,omitempty"`
+	TargetsOnFailure *string   `json:"targets_on_failure,omitempty"` // stop (default) or continue
+
+	// Target is <account_id>/<region> of a release deployed from targets
+	Target *string `json:"target,omitempty"`
 }
 
 //////////
 // Getters
 //////////
 
-// rootPath to s3
+// rootPath to s3, each account and region has its own path so they have their own locks,
+// a release from targets and one deployed directly to the same account and region share a lock
 func (release *Release) rootPath() string {
+	return fmt.Sprintf("%v/%v/%v/%v", *release.ProjectName, *release.ConfigName, to.Strs(release.AwsAccountID), to.Strs(release.AwsRegion))
+}
+
+// legacyRootPath is the root of releases before each account and region had its own,
+// its lock and halt files are still checked so upgrading cannot run two deploys at once
+func (release *Release) legacyRootPath() string {
 	return fmt.Sprintf("%v/%v", *release.ProjectName, *release.ConfigName)
 }
 
@@ -69,12 +106,35 @@ func (release *Release) LockPath() *string {
 	return &s
 }
 
+func (release *Release) legacyLockPath() *string {
+	s := fmt.Sprintf("%v/lock", release.legacyRootPath())
+	return &s
+}
+
 // HaltPath returns
 func (release *Release) HaltPath() *string {
 	s := fmt.Sprintf("%v/halt", release.rootPath())
 	return &s
 }
 
+func (release *Release) legacyHaltPath() *string {
+	s := fmt.Sprintf("%v/halt", release.legacyRootPath())
+	return &s
+}
+
+// PausePath returns, it is under the release so it cannot pause the next release
+func (release *Release) PausePath() *string {
+	s := fmt.Sprintf("%v/%v/pause", release.rootPath(), to.Strs(release.ReleaseID))
+	return &s
+}
+
+// ConsoleOutputPath returns where the console output of an instance is kept,
+// it is too big and may have secrets so it is not in the release
+func (release *Release) ConsoleOutputPath(serviceName string, instanceID string) *string {
+	s := fmt.Sprintf("%v/%v/console/%v/%v", release.rootPath(), to.Strs(release.ReleaseID), serviceName, instanceID)
+	return &s
+}
+
 // ReleasePath returns
 func (release *Release) ReleasePath() *string {
 	s := fmt.Sprintf("%v/%v/release", release.rootPath(), *release.ReleaseID)
@@ -107,9 +167,13 @@ func (release *Release) SetDefaultRegionAccount(region *string, account *string)
 	if is.EmptyStr(release.AwsRegion) {
 		release.AwsRegion = region
 	}
+}
 
-	if is.EmptyStr(release.Bucket) && account != nil {
-		release.Bucket = to.Strp(fmt.Sprintf("coinbase-step-asg-deployer-%v", *account))
+// SetDefaultBucket sets the bucket to the deployers bucket in its account,
+// i.e. the Lambda's or the clients credentials account, not the releases
+func (release *Release) SetDefaultBucket(identity *Identity, account *string) {
+	if is.EmptyStr(release.Bucket) {
+		release.Bucket = identity.BucketName(account)
 	}
 }
 
@@ -119,6 +183,10 @@ func (release *Release) SetDefaults() {
 		release.Timeout = to.Intp(600) // Default to 10 minutes
 	}
 
+	if release.MaxPause == nil {
+		release.MaxPause = to.Intp(3600) // Default to 1 hour
+	}
+
 	if release.Healthy == nil {
 		release.Healthy = to.Boolp(false)
 	}
@@ -165,47 +233,58 @@ func (release *Release) ValidateAttributes() error {
 	}
 
 	if is.EmptyStr(release.ProjectName) {
-		return fmt.Errorf("ProjectName must be defined")
+		return fieldErrorf("project_name", "ProjectName must be defined")
 	}
 
 	if is.EmptyStr(release.ConfigName) {
-		return fmt.Errorf("ConfigName must be defined")
+		return fieldErrorf("config_name", "ConfigName must be defined")
 	}
 
 	if is.EmptyStr(release.UUID) {
-		return fmt.Errorf("UUID must be defined")
+		return fieldErrorf("uuid", "UUID must be defined")
 	}
 
 	if is.EmptyStr(release.AwsRegion) {
-		return fmt.Errorf("AwsRegion must be defined")
+		return fieldErrorf("aws_region", "AwsRegion must be defined")
 	}
 
 	if is.EmptyStr(release.AwsAccountID) {
-		return fmt.Errorf("AwsAccountID must be defined")
+		return fieldErrorf("aws_account_id", "AwsAccountID must be defined")
 	}
 
 	if is.EmptyStr(release.ReleaseID) {
-		return fmt.Errorf("ReleaseID must be defined")
+		return fieldErrorf("release_id", "ReleaseID must be defined")
 	}
 
 	if is.EmptyStr(release.Bucket) {
-		return fmt.Errorf("Bucket must be defined")
+		return fieldErrorf("bucket", "Bucket must be defined")
 	}
 
 	if is.EmptyStr(release.UserData) {
-		return fmt.Errorf("UserData must be defined")
+		return fieldErrorf("user_data", "UserData must be defined")
+	}
+
+	if release.Image != nil {
+		if err := release.Image.Validate(); err != nil {
+			return fieldErrorf("ami", "AMI %v", err.Error())
+		}
+	}
+
+	// Secrets are fetched by a shell preamble so must be a script
+	if hasSecretRefs(release.UserData) && !strings.HasPrefix(*release.UserData, "#!") {
+		return fieldErrorf("user_data", "UserData with ssm or secretsmanager references must be a script starting with #!")
 	}
 
 	if release.CreatedAt == nil {
-		return fmt.Errorf("CreatedAt must be defined")
+		return fieldErrorf("created_at", "CreatedAt must be defined")
 	}
 
 	// Created at date must be after 5 mins ago, and before 2 mins from now (wiggle room)
 	if !is.WithinTimeFrame(release.CreatedAt, 300*time.Second, 120*time.Second) {
-		return fmt.Errorf("Created at older than 5 mins (or in the future)")
+		return fieldErrorf("created_at", "Created at older than 5 mins (or in the future)")
 	}
 
-	return nil
+	return release.ValidateTargets()
 }
 
 // ValidateReleaseSHA returns
@@ -228,23 +307,79 @@ func (release *Release) ValidateReleaseSHA(s3c aws.S3API) error {
 // ValidateServices returns
 func (release *Release) ValidateServices() error {
 	if release.Services == nil {
-		return fmt.Errorf("Services nil")
+		return fieldErrorf("services", "Services nil")
 	}
 
 	if len(release.Services) == 0 {
-		return fmt.Errorf("Services empty")
+		return fieldErrorf("services", "Services empty")
+	}
+
+	for name, lc := range release.LifeCycleHooks {
+		path := fmt.Sprintf("lifecycle.%v", name)
+		if lc == nil {
+			return fieldErrorf(path, "LifeCycle %v is nil", name)
+		}
+
+		if name == LaunchReadinessHookName {
+			return fieldErrorf(path, "LifeCycle name %v is reserved for launch_readiness", name)
+		}
+
+		if err := lc.ValidateAttributes(); err != nil {
+			return inPath(path, err)
+		}
 	}
 
 	for name, service := range release.Services {
+		path := fmt.Sprintf("services.%v", name)
 		if service == nil {
-			return fmt.Errorf("Service %v is nil", name)
+			return fieldErrorf(path, "Service %v is nil", name)
+		}
+
+		if err := service.Validate(); err != nil {
+			return inPath(path, err)
 		}
+	}
+
+	if err := release.validateDependencies(); err != nil {
+		return err
+	}
+
+	if err := release.ValidateOnlyServices(); err != nil {
+		return err
+	}
+
+	return nil
+}
 
-		err := service.Validate()
-		if err != nil {
-			return err
+// ValidateOnlyServices returns an error if only_services includes a service not in the release
+func (release *Release) ValidateOnlyServices() error {
+	seen := []string{}
+	for i, name := range release.OnlyServices {
+		path := indexPath("only_services", i)
+		switch {
+		case is.EmptyStr(name):
+			return fieldErrorf(path, "OnlyServices cannot include an empty service")
+		case release.Services[*name] == nil:
+			return fieldErrorf(path, "OnlyServices includes unknown service %v", *name)
+		case inStrs(seen, *name):
+			return fieldErrorf(path, "OnlyServices includes %v more than once", *name)
 		}
+		seen = append(seen, *name)
 	}
 
 	return nil
 }
+
+// SelectServices removes the services not in only_services from the release
+// so they are not fetched, created or torn down
+func (release *Release) SelectServices() {
+	if len(release.OnlyServices) == 0 {
+		return
+	}
+
+	for name := range release.Services {
+		if !inStrs(to.StrSlice(release.OnlyServices), name) {
+			delete(release.Services, name)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/coinbase/step-asg-deployer/aws"
 	"github.com/coinbase/step/aws/s3"
 	"github.com/coinbase/step/utils/is"
@@ -14,26 +17,88 @@ import (
 // Halt
 /////////
 
+// HaltRequest is written to the halt file, without a scope it halts any release of the project-config
+type HaltRequest struct {
+	Reason    *string   `json:"reason,omitempty"`
+	HaltedBy  *string   `json:"halted_by,omitempty"`
+	ReleaseID *string   `json:"release_id,omitempty"` // Only halt this release
+	Services  []*string `json:"services,omitempty"`   // Only halt releases deploying one of these services
+}
+
+// Message returns why the release was halted
+func (halt *HaltRequest) Message() string {
+	switch {
+	case halt.HaltedBy != nil && halt.Reason != nil:
+		return fmt.Sprintf("Halted by %v: %v", *halt.HaltedBy, *halt.Reason)
+	case halt.HaltedBy != nil:
+		return fmt.Sprintf("Halted by %v", *halt.HaltedBy)
+	case halt.Reason != nil:
+		return fmt.Sprintf("Halted: %v", *halt.Reason)
+	}
+	return "Halt File Found"
+}
+
+// appliesTo returns true if the release is in the scope of the halt
+func (halt *HaltRequest) appliesTo(release *Release) bool {
+	if halt.ReleaseID != nil && to.Strs(release.ReleaseID) != *halt.ReleaseID {
+		return false
+	}
+
+	if len(halt.Services) == 0 {
+		return true
+	}
+
+	// Services not being deployed have been removed from the release
+	for _, name := range halt.Services {
+		if name == nil {
+			continue
+		}
+		if _, ok := release.Services[*name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TimeoutError is returned by IsHalt when the release took too long, unlike a halt
+type TimeoutError struct {
+	err error
+}
+
+// Error returns error
+func (te *TimeoutError) Error() string {
+	return te.err.Error()
+}
+
 // IsHalt will try but no guarantees to work
 func (release *Release) IsHalt(s3c aws.S3API) error {
 	now := time.Now()
 
-	timeout := release.CreatedAt.Add(time.Second * time.Duration(*release.Timeout))
+	// Time spent paused does not count towards the timeout
+	timeout := release.CreatedAt.Add(time.Second * time.Duration(*release.Timeout+release.pausedSeconds(now)))
 
 	if now.After(timeout) {
-		return fmt.Errorf("Timeout: Halting Service")
+		return &TimeoutError{fmt.Errorf("Timeout: Halting Service")}
+	}
+
+	if release.MaxPause != nil && release.pausedSeconds(now) > *release.MaxPause {
+		return &TimeoutError{fmt.Errorf("Timeout: Paused longer than max_pause %v seconds", *release.MaxPause)}
 	}
 
-	if isHalt(s3c, release.Bucket, release.HaltPath()) {
-		return fmt.Errorf("Halt File Found")
+	if halt := release.haltRequest(s3c); halt != nil {
+		return errors.New(halt.Message())
 	}
 
 	return nil
 }
 
-// Halt returns
-func (release *Release) Halt(s3c aws.S3API) error {
-	return s3.Put(s3c, release.Bucket, release.HaltPath(), to.Strp("halt"))
+// Halt writes the halt file, a nil request halts any release
+func (release *Release) Halt(s3c aws.S3API, halt *HaltRequest) error {
+	if halt == nil {
+		halt = &HaltRequest{}
+	}
+	return s3.PutStruct(s3c, release.Bucket, release.HaltPath(), halt)
 }
 
 // RemoveHalt returns
@@ -44,18 +109,129 @@ func (release *Release) RemoveHalt(s3c aws.S3API) {
 	}
 }
 
-func isHalt(s3c aws.S3API, bucket *string, haltPath *string) bool {
-	lm, err := s3.GetLastModified(s3c, bucket, haltPath)
+// haltRequest returns the request in the halt file if it applies to the release,
+// halt files at the legacy path are written by older clients
+func (release *Release) haltRequest(s3c aws.S3API) *HaltRequest {
+	if halt := release.haltRequestAt(s3c, release.HaltPath()); halt != nil {
+		return halt
+	}
+	return release.haltRequestAt(s3c, release.legacyHaltPath())
+}
+
+func (release *Release) haltRequestAt(s3c aws.S3API, path *string) *HaltRequest {
+	lm, err := s3.GetLastModified(s3c, release.Bucket, path)
+
+	// If no file or any error return nil
+	if err != nil || lm == nil {
+		return nil
+	}
+
+	var halt HaltRequest
+	if err := s3.GetStruct(s3c, release.Bucket, path, &halt); err != nil {
+		if _, ok := err.(awserr.Error); ok {
+			return nil
+		}
+		// Halt files written by older clients are not JSON and halt any release
+		halt = HaltRequest{}
+	}
+
+	if !halt.appliesTo(release) {
+		return nil
+	}
+
+	// A halt for a release cannot halt the next one so it does not expire,
+	// otherwise check halt was written in last 5 mins, and before a 2 mins in the future
+	if halt.ReleaseID == nil && !is.WithinTimeFrame(lm, 5*time.Minute, 2*time.Minute) {
+		return nil
+	}
+
+	return &halt
+}
+
+/////////
+// Pause
+/////////
+
+// Pause writes the pause file
+func (release *Release) Pause(s3c aws.S3API) error {
+	return s3.Put(s3c, release.Bucket, release.PausePath(), to.Strp("pause"))
+}
+
+// Resume deletes the pause file
+func (release *Release) Resume(s3c aws.S3API) error {
+	return s3.Delete(s3c, release.Bucket, release.PausePath())
+}
+
+// RemovePause returns
+func (release *Release) RemovePause(s3c aws.S3API) {
+	if err := release.Resume(s3c); err != nil {
+		// ignore errors
+		fmt.Printf("Warning(RemovePause) error ignored: %v\n", err.Error())
+	}
+}
+
+// IsPaused returns true if the pause file exists, unlike halt it does not expire
+func (release *Release) IsPaused(s3c aws.S3API) (bool, error) {
+	lm, err := s3.GetLastModified(s3c, release.Bucket, release.PausePath())
 
-	// If no file or any error return false
 	if err != nil {
-		return false
+		if isNotFound(err) {
+			return false, nil
+		}
+		// Not knowing is not the same as not paused
+		return false, err
 	}
 
-	if lm == nil {
+	return lm != nil, nil
+}
+
+// UpdatePaused records when a pause starts and ends and returns true while paused
+func (release *Release) UpdatePaused(s3c aws.S3API) (bool, error) {
+	now := time.Now()
+
+	paused, err := release.IsPaused(s3c)
+	if err != nil {
+		return false, err
+	}
+
+	if paused {
+		if release.PausedAt == nil {
+			release.PausedAt = &now
+		}
+		release.Paused = to.Boolp(true)
+		return true, nil
+	}
+
+	if release.PausedAt != nil {
+		release.PausedSeconds = to.Intp(release.pausedSeconds(now))
+		release.PausedAt = nil
+	}
+
+	release.Paused = to.Boolp(false)
+	return false, nil
+}
+
+// pausedSeconds returns how long the release has been paused including the current pause
+func (release *Release) pausedSeconds(now time.Time) int {
+	seconds := 0
+	if release.PausedSeconds != nil {
+		seconds = *release.PausedSeconds
+	}
+
+	if release.PausedAt != nil {
+		seconds += int(now.Sub(*release.PausedAt).Seconds())
+	}
+
+	return seconds
+}
+
+// isNotFound returns true if the S3 error is for a missing object
+func isNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
 		return false
 	}
 
-	// check halt was written in last 5 mins, and before a 2 mins in the future
-	return is.WithinTimeFrame(lm, 5*time.Minute, 2*time.Minute)
+	// GetObject returns NoSuchKey and HeadObject returns NotFound
+	return aerr.Code() == awss3.ErrCodeNoSuchKey || aerr.Code() == "NotFound"
 }
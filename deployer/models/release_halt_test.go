@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/coinbase/step-asg-deployer/aws/mocks"
 	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
@@ -24,7 +25,7 @@ func Test_IsHalt_ReleaseTimeout(t *testing.T) {
 	r.Timeout = to.Intp(10)
 	assert.NoError(t, r.IsHalt(awsc.S3))
 	r.CreatedAt = to.Timep(time.Now().Add(-1 * (11 * time.Second)))
-	assert.Error(t, r.IsHalt(awsc.S3))
+	assert.IsType(t, &TimeoutError{}, r.IsHalt(awsc.S3))
 }
 
 func Test_IsHalt_HaltKey(t *testing.T) {
@@ -34,10 +35,142 @@ func Test_IsHalt_HaltKey(t *testing.T) {
 
 	awsc := mocks.MockAWS()
 	assert.NoError(t, r.IsHalt(awsc.S3))
-	assert.NoError(t, r.Halt(awsc.S3))
+	assert.NoError(t, r.Halt(awsc.S3, nil))
 	assert.Error(t, r.IsHalt(awsc.S3))
 
 	// If the Halt key is older than 5 mins ignore it
 	awsc.S3.GetObjectResp[*r.HaltPath()].Resp.LastModified = to.Timep(time.Now().Add(-1 * (10 * time.Minute)))
 	assert.NoError(t, r.IsHalt(awsc.S3))
 }
+
+func Test_IsHalt_LegacyHaltKey(t *testing.T) {
+	r := MockMinimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	r.SetDefaults()
+
+	// Older clients write the halt file without the account and region
+	awsc := mocks.MockAWS()
+	awsc.S3.AddGetObject("project/config/halt", "", nil)
+	assert.Error(t, r.IsHalt(awsc.S3))
+}
+
+func Test_IsHalt_PausedExtendsTimeout(t *testing.T) {
+	r := MockMinimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	r.SetDefaults()
+
+	awsc := mocks.MockAWS()
+	r.CreatedAt = to.Timep(time.Now().Add(-1 * (11 * time.Second)))
+	r.Timeout = to.Intp(10)
+	assert.Error(t, r.IsHalt(awsc.S3))
+
+	// Paused for 5 seconds
+	r.PausedAt = to.Timep(time.Now().Add(-1 * (5 * time.Second)))
+	assert.NoError(t, r.IsHalt(awsc.S3))
+
+	// Resuming keeps the time paused
+	paused, err := r.UpdatePaused(awsc.S3)
+	assert.NoError(t, err)
+	assert.False(t, paused)
+	assert.Nil(t, r.PausedAt)
+	assert.Equal(t, 5, *r.PausedSeconds)
+	assert.NoError(t, r.IsHalt(awsc.S3))
+}
+
+func Test_IsHalt_MaxPause(t *testing.T) {
+	r := MockMinimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	r.SetDefaults()
+	assert.Equal(t, 3600, *r.MaxPause)
+
+	awsc := mocks.MockAWS()
+	r.CreatedAt = to.Timep(time.Now())
+	r.MaxPause = to.Intp(10)
+
+	r.PausedAt = to.Timep(time.Now().Add(-1 * (5 * time.Second)))
+	assert.NoError(t, r.IsHalt(awsc.S3))
+
+	// Paused too long halts even though the timeout is extended
+	r.PausedSeconds = to.Intp(6)
+	err := r.IsHalt(awsc.S3)
+	assert.IsType(t, &TimeoutError{}, err)
+	assert.Contains(t, err.Error(), "max_pause")
+}
+
+func Test_UpdatePaused(t *testing.T) {
+	r := MockMinimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	r.SetDefaults()
+	r.ReleaseID = to.Strp("rr")
+
+	awsc := mocks.MockAWS()
+	paused, err := r.UpdatePaused(awsc.S3)
+	assert.NoError(t, err)
+	assert.False(t, paused)
+	assert.False(t, *r.Paused)
+
+	assert.NoError(t, r.Pause(awsc.S3))
+	paused, err = r.UpdatePaused(awsc.S3)
+	assert.NoError(t, err)
+	assert.True(t, paused)
+	assert.True(t, *r.Paused)
+	assert.NotNil(t, r.PausedAt)
+
+	// Unlike halt an old pause file is not ignored
+	awsc.S3.GetObjectResp[*r.PausePath()].Resp.LastModified = to.Timep(time.Now().Add(-1 * (10 * time.Minute)))
+	paused, err = r.IsPaused(awsc.S3)
+	assert.NoError(t, err)
+	assert.True(t, paused)
+
+	// The pause is for this release only
+	next := MockMinimalRelease(t)
+	next.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	next.ReleaseID = to.Strp("next")
+	paused, err = next.IsPaused(awsc.S3)
+	assert.NoError(t, err)
+	assert.False(t, paused)
+
+	// Errors other than not found are not treated as not paused
+	awsc.S3.AddGetObject(*r.PausePath(), "", awserr.New("SlowDown", "Please reduce your request rate", nil))
+	_, err = r.UpdatePaused(awsc.S3)
+	assert.Error(t, err)
+	assert.True(t, *r.Paused)
+}
+
+func Test_IsHalt_HaltRequest(t *testing.T) {
+	r := MockMinimalRelease(t)
+	r.SetDefaultRegionAccount(to.Strp("region"), to.Strp("account"))
+	r.SetDefaults()
+	r.ReleaseID = to.Strp("rr")
+
+	awsc := mocks.MockAWS()
+	assert.NoError(t, r.Halt(awsc.S3, &HaltRequest{HaltedBy: to.Strp("alice"), Reason: to.Strp("elevated 5xx")}))
+
+	err := r.IsHalt(awsc.S3)
+	assert.Error(t, err)
+	assert.Equal(t, "Halted by alice: elevated 5xx", err.Error())
+
+	// Halt for another release
+	assert.NoError(t, r.Halt(awsc.S3, &HaltRequest{ReleaseID: to.Strp("other")}))
+	assert.NoError(t, r.IsHalt(awsc.S3))
+
+	// Halt for this release does not expire
+	assert.NoError(t, r.Halt(awsc.S3, &HaltRequest{ReleaseID: to.Strp("rr"), Reason: to.Strp("bad")}))
+	awsc.S3.GetObjectResp[*r.HaltPath()].Resp.LastModified = to.Timep(time.Now().Add(-1 * (10 * time.Minute)))
+	err = r.IsHalt(awsc.S3)
+	assert.Error(t, err)
+	assert.Equal(t, "Halted: bad", err.Error())
+
+	// Halt for services not in the release
+	assert.NoError(t, r.Halt(awsc.S3, &HaltRequest{Services: []*string{to.Strp("unknown")}}))
+	assert.NoError(t, r.IsHalt(awsc.S3))
+
+	assert.NoError(t, r.Halt(awsc.S3, &HaltRequest{Services: []*string{to.Strp("web")}}))
+	assert.Error(t, r.IsHalt(awsc.S3))
+}
+
+func Test_HaltRequest_Message(t *testing.T) {
+	assert.Equal(t, "Halt File Found", (&HaltRequest{}).Message())
+	assert.Equal(t, "Halted by alice", (&HaltRequest{HaltedBy: to.Strp("alice")}).Message())
+	assert.Equal(t, "Halted: bad", (&HaltRequest{Reason: to.Strp("bad")}).Message())
+}
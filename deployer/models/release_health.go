@@ -0,0 +1,115 @@
+package models
+
+import (
+	"sort"
+
+	aws_elb "github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/alb"
+	"github.com/coinbase/step-asg-deployer/aws/elb"
+)
+
+// healthCheck collects the instances of a service across its ASG, ELBs and Target Groups
+type healthCheck struct {
+	service *Service
+	all     aws.Instances
+	details aws.InstanceDetails
+}
+
+// updateHealthy sets the health of the services, every ELB and Target Group
+// is described once with the instances of all the services attached to it
+func updateHealthy(services []*Service, asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI) error {
+	checks := []*healthCheck{}
+	elbIDs := map[string][]string{}
+	tgIDs := map[string][]string{}
+
+	for _, service := range services {
+		check, err := service.asgHealth(asgc)
+		if err != nil {
+			return err
+		}
+
+		checks = append(checks, check)
+
+		for _, name := range service.Resources.ELBs {
+			elbIDs[*name] = append(elbIDs[*name], check.all.InstanceIDs()...)
+		}
+
+		for _, arn := range service.Resources.TargetGroups {
+			tgIDs[*arn] = append(tgIDs[*arn], check.all.InstanceIDs()...)
+		}
+	}
+
+	elbStates := map[string][]*aws_elb.InstanceState{}
+	for _, name := range sortedKeys(elbIDs) {
+		states, err := elb.DescribeInstanceHealth(elbc, &name, elbIDs[name])
+		if err != nil {
+			return err // This might retry
+		}
+		elbStates[name] = states
+	}
+
+	tgStates := map[string][]*elbv2.TargetHealthDescription{}
+	for _, arn := range sortedKeys(tgIDs) {
+		thds, err := alb.DescribeTargetHealth(albc, &arn, tgIDs[arn])
+		if err != nil {
+			return err // This might retry
+		}
+		tgStates[arn] = thds
+	}
+
+	for _, check := range checks {
+		check.merge(elbStates, tgStates)
+		check.service.setHealthy(check.all)
+		check.service.setDiagnostics(check.all, check.details)
+	}
+
+	return nil
+}
+
+// merge adds the ELB and Target Group states of only this services instances
+func (check *healthCheck) merge(elbStates map[string][]*aws_elb.InstanceState, tgStates map[string][]*elbv2.TargetHealthDescription) {
+	for _, name := range check.service.Resources.ELBs {
+		elbInstances := aws.Instances{}
+		for _, is := range elbStates[*name] {
+			if !check.owns(is.InstanceId) {
+				continue
+			}
+			elbInstances.AddELBInstance(is)
+			check.details.AddELBInstance(*name, is)
+		}
+
+		check.all = check.all.MergeInstances(elbInstances)
+	}
+
+	for _, arn := range check.service.Resources.TargetGroups {
+		tgInstances := aws.Instances{}
+		for _, thd := range tgStates[*arn] {
+			if thd.Target == nil || !check.owns(thd.Target.Id) {
+				continue
+			}
+			tgInstances.AddTargetGroupInstance(thd)
+			check.details.AddTargetGroupInstance(*arn, thd)
+		}
+
+		check.all = check.all.MergeInstances(tgInstances)
+	}
+}
+
+func (check *healthCheck) owns(id *string) bool {
+	if id == nil {
+		return false
+	}
+	_, ok := check.all[*id]
+	return ok
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
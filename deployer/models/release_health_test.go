@@ -0,0 +1,79 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSharedRelease returns a created release with services sharing the web ELB and Target Group
+func mockSharedRelease(t testing.TB, services int) (*Release, *mocks.MockClients) {
+	r := MockRelease(t)
+	for i := 1; i < services; i++ {
+		r.Services[fmt.Sprintf("api%v", i)] = &Service{InstanceType: to.Strp("t2.small")}
+	}
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+	if err := r.CreateResources(awsc.ASG, awsc.CW); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, service := range r.Services {
+		service.Resources.ELBs = []*string{to.Strp("web-elb")}
+		service.Resources.TargetGroups = []*string{to.Strp("web-elb-target")}
+	}
+
+	return r, awsc
+}
+
+func Test_Release_UpdateHealthy_BatchesHealthChecks(t *testing.T) {
+	r, awsc := mockSharedRelease(t, 3)
+
+	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB))
+	assert.True(t, r.Services["web"].Healthy)
+
+	// One call per ELB and Target Group not per service
+	assert.Equal(t, 1, awsc.ELB.DescribeInstanceHealthCalls)
+	assert.Equal(t, 1, awsc.ALB.DescribeTargetHealthCalls)
+}
+
+func Benchmark_Release_UpdateHealthy(b *testing.B) {
+	b.Run("batched", func(b *testing.B) {
+		r, awsc := mockSharedRelease(b, 10)
+
+		for i := 0; i < b.N; i++ {
+			if err := r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.Logf("%v services: %v DescribeInstanceHealth and %v DescribeTargetHealth calls per check",
+			len(r.Services),
+			awsc.ELB.DescribeInstanceHealthCalls/b.N,
+			awsc.ALB.DescribeTargetHealthCalls/b.N,
+		)
+	})
+
+	// Without batching every service describes the ELB and Target Group
+	b.Run("unbatched", func(b *testing.B) {
+		r, awsc := mockSharedRelease(b, 10)
+
+		for i := 0; i < b.N; i++ {
+			for _, service := range r.createdServices() {
+				if err := updateHealthy([]*Service{service}, awsc.ASG, awsc.ELB, awsc.ALB); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+
+		b.Logf("%v services: %v DescribeInstanceHealth and %v DescribeTargetHealth calls per check",
+			len(r.Services),
+			awsc.ELB.DescribeInstanceHealthCalls/b.N,
+			awsc.ALB.DescribeTargetHealthCalls/b.N,
+		)
+	})
+}
@@ -3,14 +3,43 @@ package models
 import (
 	"github.com/coinbase/step/aws"
 	"github.com/coinbase/step/aws/s3"
+	"github.com/coinbase/step/utils/to"
 )
 
-// GrabLock tries to grab the lock
+// lockFile is the content of a lock file
+type lockFile struct {
+	UUID *string `json:"uuid"`
+}
+
+// GrabLock tries to grab the lock, it is not grabbed while a release holds the legacy lock
 func (release *Release) GrabLock(s3Client aws.S3API) (bool, error) {
+	held, err := release.legacyLockHeld(s3Client)
+	if err != nil || held {
+		return false, err
+	}
+
 	return s3.GrabLock(s3Client, release.Bucket, release.LockPath(), *release.UUID)
 }
 
+// legacyLockHeld returns true if another release holds the lock at the legacy path
+func (release *Release) legacyLockHeld(s3Client aws.S3API) (bool, error) {
+	var lock lockFile
+	if err := s3.GetStruct(s3Client, release.Bucket, release.legacyLockPath(), &lock); err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return to.Strs(lock.UUID) != to.Strs(release.UUID), nil
+}
+
 // ReleaseLock tries to release the lock
 func (release *Release) ReleaseLock(s3Client aws.S3API) error {
 	return s3.ReleaseLock(s3Client, release.Bucket, release.LockPath(), *release.UUID)
 }
+
+// ClearLock deletes the lock whichever release holds it, only to recover from FailureDirty
+func (release *Release) ClearLock(s3Client aws.S3API) error {
+	return s3.Delete(s3Client, release.Bucket, release.LockPath())
+}
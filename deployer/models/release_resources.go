@@ -2,11 +2,13 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/coinbase/step-asg-deployer/aws"
 	"github.com/coinbase/step-asg-deployer/aws/ami"
 	"github.com/coinbase/step-asg-deployer/aws/asg"
 	"github.com/coinbase/step-asg-deployer/aws/subnet"
+	"github.com/coinbase/step/utils/to"
 )
 
 //////////
@@ -15,7 +17,7 @@ import (
 
 // FetchResources checks the existence of all Resources references in this release
 // and returns a struct of the resources
-func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI, snsc aws.SNSAPI) (map[string]*ServiceResources, error) {
+func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI, snsc aws.SNSAPI, sqsc aws.SQSAPI, ssmc aws.SSMAPI, smc aws.SecretsManagerAPI) (map[string]*ServiceResources, error) {
 	resources := map[string]*ServiceResources{}
 
 	// If there are any ASGs with this release ID error
@@ -47,13 +49,13 @@ func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws
 
 	// LifeCycleHooks
 	for _, lc := range release.LifeCycleHooks {
-		if err := lc.FetchResources(iamc, snsc); err != nil {
+		if err := lc.FetchResources(iamc, snsc, sqsc); err != nil {
 			return nil, err
 		}
 	}
 
 	for name, service := range release.Services {
-		sr, err := service.FetchResources(ec2, elbc, albc, iamc)
+		sr, err := service.FetchResources(ec2, elbc, albc, iamc, ssmc, smc)
 		if err != nil {
 			return nil, err
 		}
@@ -69,7 +71,7 @@ func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws
 }
 
 // ValidateResources returns
-func (release *Release) ValidateResources(resources map[string]*ServiceResources) error {
+func (release *Release) ValidateResources(resources map[string]*ServiceResources, policy *DeployerPolicy) error {
 	// Fetch Service
 	for name, service := range release.Services {
 		sr := resources[name]
@@ -79,6 +81,20 @@ func (release *Release) ValidateResources(resources map[string]*ServiceResources
 		if err := sr.Validate(service); err != nil {
 			return err
 		}
+
+		if err := policy.ValidateService(service); err != nil {
+			return err
+		}
+
+		if err := policy.ValidateImage(release.ConfigName, sr.Image); err != nil {
+			return err
+		}
+
+		for _, r := range sr.SecurityGroups {
+			if err := policy.ValidateSecurityGroup(release.AwsAccountID, r); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
@@ -105,14 +121,17 @@ func (release *Release) UpdateWithResources(resources map[string]*ServiceResourc
 // Create Resources
 //////////
 
-// CreateResources returns
+// CreateResources creates the services whose dependencies are healthy,
+// it is called again for each stage until all services are created
 func (release *Release) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
-	for _, service := range release.Services {
+	for _, service := range release.servicesToCreate() {
 		err := service.CreateResources(asgc, cwc)
 		if err != nil {
 			return err
 		}
 	}
+
+	release.NextStage = to.Boolp(false)
 	return nil
 }
 
@@ -123,18 +142,40 @@ func (release *Release) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 // UpdateHealthy will try set the Healthy attribute
 // First Error is a Halting Error, Second Error is a Retry Error
 func (release *Release) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI) error {
+	created := release.createdServices()
+
+	// Services sharing ELBs or Target Groups are checked in one call
+	if err := updateHealthy(created, asgc, elbc, albc); err != nil {
+		return err
+	}
+
 	healthy := true
+	for _, service := range created {
+		healthy = healthy && service.Healthy // Healthy if all services are healthy
+	}
 
-	for _, service := range release.Services {
+	// Services waiting on dependencies are created in the next stage
+	nextStage := healthy && len(created) < len(release.serviceNames())
 
-		if err := service.UpdateHealthy(asgc, elbc, albc); err != nil {
-			return err
-		}
+	release.Healthy = to.Boolp(healthy && !nextStage)
+	release.NextStage = &nextStage
 
-		healthy = healthy && service.Healthy // Healthy if all services are healthy
+	return nil
+}
+
+// Diagnose adds diagnostics to every service to explain why the release failed
+// one service failing to be diagnosed does not stop the others
+func (release *Release) Diagnose(asgc aws.ASGAPI, ec2c aws.EC2API, s3c aws.S3API) error {
+	errs := []string{}
+	for _, name := range release.serviceNames() {
+		if err := release.Services[name].Diagnose(asgc, ec2c, s3c); err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", name, err.Error()))
+		}
 	}
 
-	release.Healthy = &healthy
+	if len(errs) > 0 {
+		return fmt.Errorf("Diagnose Error: %v", strings.Join(errs, ", "))
+	}
 
 	return nil
 }
@@ -146,7 +187,8 @@ func (release *Release) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws
 // SuccessfulTearDown returns
 func (release *Release) SuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 	// Tear down all resources in NOT in this release
-	asgs, err := asg.ForProjectConfigNOTReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID)
+	// Only tear down the services in only_services if it is set
+	asgs, err := asg.ForProjectConfigNOTReleaseID(asgc, release.ProjectName, release.ConfigName, release.ReleaseID, release.OnlyServices)
 
 	if err != nil {
 		return err
@@ -3,33 +3,35 @@ package models
 import (
 	"testing"
 
+	"github.com/coinbase/step-asg-deployer/aws/asg"
+	"github.com/coinbase/step/utils/to"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_Release_FetchResources_Works(t *testing.T) {
-	// func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI, snsc aws.SNSAPI) (map[string]*ServiceResources, error)
+	// func (release *Release) FetchResources(asgc aws.ASGAPI, ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI, snsc aws.SNSAPI, sqsc aws.SQSAPI, ssmc aws.SSMAPI, smc aws.SecretsManagerAPI) (map[string]*ServiceResources, error)
 	r := MockRelease(t)
 	MockPrepareRelease(r)
 
 	awsc := MockAwsClients(r)
 
-	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
 	assert.NoError(t, err)
 
 	assert.Equal(t, 1, len(sm))
 }
 
 func Test_Release_ValidateResources_Works(t *testing.T) {
-	// func (release *Release) ValidateResources(resources map[string]*ServiceResources) error {
+	// func (release *Release) ValidateResources(resources map[string]*ServiceResources, policy *DeployerPolicy) error {
 	r := MockRelease(t)
 	MockPrepareRelease(r)
 
 	awsc := MockAwsClients(r)
 
-	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
 	assert.NoError(t, err)
 
-	assert.NoError(t, r.ValidateResources(sm))
+	assert.NoError(t, r.ValidateResources(sm, &DeployerPolicy{}))
 }
 
 func Test_Release_UpdateWithResources_Works(t *testing.T) {
@@ -39,7 +41,7 @@ func Test_Release_UpdateWithResources_Works(t *testing.T) {
 
 	awsc := MockAwsClients(r)
 
-	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS)
+	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
 	assert.NoError(t, err)
 
 	r.UpdateWithResources(sm)
@@ -65,6 +67,45 @@ func Test_Release_UpdateHealthy_Works(t *testing.T) {
 	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB))
 }
 
+func Test_Release_UpdateHealthy_FailedLaunches(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	awsc := MockAwsClients(r)
+	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW))
+
+	// Activities are only checked while the ASG is short of instances
+	awsc.ASG.AddLaunchActivity("Failed", "The image id '[ami-123456]' does not exist. Launching EC2 instance failed.")
+	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB))
+	assert.Equal(t, 0, awsc.ASG.DescribeScalingActivitiesCalls)
+
+	awsc.ASG.Activities = nil
+	awsc.ASG.DescribeAutoScalingGroupsPageResp[0].Resp.AutoScalingGroups[0].Instances = nil
+	r.Services["web"].Healthy = false
+
+	// Capacity might be found if retried
+	capacity := "We currently do not have sufficient t2.small capacity in the Availability Zone you requested"
+	awsc.ASG.AddLaunchActivity("Failed", capacity)
+	awsc.ASG.AddLaunchActivity("Failed", capacity)
+	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB))
+
+	awsc.ASG.AddLaunchActivity("Failed", capacity)
+	err := r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB)
+	assert.IsType(t, &HaltError{}, err)
+	assert.Contains(t, err.Error(), "3 times")
+
+	// A bad AMI will never launch
+	r = MockRelease(t)
+	MockPrepareRelease(r)
+	awsc = MockAwsClients(r)
+	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW))
+	awsc.ASG.DescribeAutoScalingGroupsPageResp[0].Resp.AutoScalingGroups[0].Instances = nil
+
+	awsc.ASG.AddLaunchActivity("Failed", "The image id '[ami-123456]' does not exist. Launching EC2 instance failed.")
+	err = r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB)
+	assert.IsType(t, &HaltError{}, err)
+	assert.Contains(t, err.Error(), "ami-123456")
+}
+
 func Test_Release_SuccessfulTearDown_Works(t *testing.T) {
 	// func (release *Release) SuccessfulTearDown(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 	r := MockRelease(t)
@@ -82,3 +123,24 @@ func Test_Release_UnsuccssfulTearDown_Works(t *testing.T) {
 	awsc := MockAwsClients(r)
 	assert.NoError(t, r.UnsuccssfulTearDown(awsc.ASG, awsc.CW))
 }
+
+func Test_Release_SuccessfulTearDown_OnlyServices(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+	awsc.ASG.AddPreviousRuntimeResources(*r.ProjectName, *r.ConfigName, "worker", "old-release")
+
+	asgs, err := asg.ForProjectConfigNOTReleaseID(awsc.ASG, r.ProjectName, r.ConfigName, r.ReleaseID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(asgs))
+
+	// Only the web ASG is torn down
+	r.OnlyServices = []*string{to.Strp("web")}
+	asgs, err = asg.ForProjectConfigNOTReleaseID(awsc.ASG, r.ProjectName, r.ConfigName, r.ReleaseID, r.OnlyServices)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(asgs))
+	assert.Equal(t, "web", *asgs[0].ServiceName())
+
+	assert.NoError(t, r.SuccessfulTearDown(awsc.ASG, awsc.CW))
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//////////
+// Stages
+//////////
+
+// Services are deployed in stages, a service is only created once
+// every service in its depends_on is healthy
+
+// validateDependencies returns an error if depends_on references an unknown service or has a cycle
+func (release *Release) validateDependencies() error {
+	for _, name := range release.serviceNames() {
+		path := fmt.Sprintf("services.%v.depends_on", name)
+		seen := []string{}
+
+		for _, dep := range release.Services[name].DependsOn {
+			switch {
+			case dep == nil || *dep == "":
+				return fieldErrorf(path, "DependsOn cannot include an empty service")
+			case *dep == name:
+				return fieldErrorf(path, "Service %v cannot depend on itself", name)
+			case release.Services[*dep] == nil:
+				return fieldErrorf(path, "Service %v depends on unknown service %v", name, *dep)
+			case inStrs(seen, *dep):
+				return fieldErrorf(path, "Service %v depends on %v more than once", name, *dep)
+			}
+			seen = append(seen, *dep)
+		}
+	}
+
+	for _, name := range release.serviceNames() {
+		if cycle := release.dependencyCycle(name, []string{}); cycle != nil {
+			return fieldErrorf(fmt.Sprintf("services.%v.depends_on", name), "Dependency cycle %v", strings.Join(cycle, " -> "))
+		}
+	}
+
+	return nil
+}
+
+// dependencyCycle returns the path back to a service already visited, or nil
+func (release *Release) dependencyCycle(name string, visited []string) []string {
+	visited = append(visited, name)
+	for _, dep := range release.Services[name].DependsOn {
+		if inStrs(visited, *dep) {
+			return append(visited, *dep)
+		}
+
+		if cycle := release.dependencyCycle(*dep, visited); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// servicesToCreate returns the services not yet created whose dependencies are healthy
+func (release *Release) servicesToCreate() []*Service {
+	services := []*Service{}
+	for _, name := range release.serviceNames() {
+		service := release.Services[name]
+		if service.CreatedASG != nil {
+			continue
+		}
+
+		if release.dependenciesHealthy(service) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+func (release *Release) dependenciesHealthy(service *Service) bool {
+	for _, dep := range service.DependsOn {
+		depService := release.Services[*dep]
+		if depService == nil {
+			continue // Not selected in only_services so already deployed
+		}
+
+		if depService.CreatedASG == nil || !depService.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// createdServices returns the services that have an ASG in this release
+func (release *Release) createdServices() []*Service {
+	services := []*Service{}
+	for _, name := range release.serviceNames() {
+		if service := release.Services[name]; service.CreatedASG != nil {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+func (release *Release) serviceNames() []string {
+	names := []string{}
+	for name, service := range release.Services {
+		if service != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step-asg-deployer/aws/mocks"
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockStagedRelease(t *testing.T) *Release {
+	r := MockRelease(t)
+	r.Services["worker"] = &Service{
+		InstanceType: to.Strp("t2.small"),
+		DependsOn:    []*string{to.Strp("web")},
+	}
+	MockPrepareRelease(r)
+	return r
+}
+
+func serviceNamesOf(services []*Service) []string {
+	names := []string{}
+	for _, service := range services {
+		names = append(names, *service.ServiceName)
+	}
+	return names
+}
+
+func Test_Release_validateDependencies(t *testing.T) {
+	r := mockStagedRelease(t)
+	assert.NoError(t, r.validateDependencies())
+
+	cases := map[string][]*string{
+		"Service worker depends on unknown service db": []*string{to.Strp("db")},
+		"Service worker cannot depend on itself":       []*string{to.Strp("worker")},
+		"Service worker depends on web more than once": []*string{to.Strp("web"), to.Strp("web")},
+	}
+
+	for msg, deps := range cases {
+		r.Services["worker"].DependsOn = deps
+		err := r.validateDependencies()
+		assert.Error(t, err)
+		assert.Equal(t, "services.worker.depends_on", err.(*FieldError).Path)
+		assert.Equal(t, msg, err.(*FieldError).Err)
+	}
+
+	r.Services["worker"].DependsOn = []*string{to.Strp("web")}
+	r.Services["web"].DependsOn = []*string{to.Strp("worker")}
+	err := r.validateDependencies()
+	assert.Error(t, err)
+	assert.Equal(t, "services.web.depends_on: Dependency cycle web -> worker -> web", err.Error())
+}
+
+func Test_Release_ValidateServices_DependsOn(t *testing.T) {
+	r := mockStagedRelease(t)
+	r.Services["worker"].DependsOn = []*string{to.Strp("db")}
+
+	err := r.ValidateServices()
+	assert.Error(t, err)
+	assert.Equal(t, "services.worker.depends_on", err.(*FieldError).Path)
+}
+
+func Test_Release_servicesToCreate(t *testing.T) {
+	r := mockStagedRelease(t)
+	assert.Equal(t, []string{"web"}, serviceNamesOf(r.servicesToCreate()))
+
+	// worker waits for web to be healthy
+	r.Services["web"].CreatedASG = to.Strp("web-asg")
+	assert.Equal(t, []string{}, serviceNamesOf(r.servicesToCreate()))
+
+	r.Services["web"].Healthy = true
+	assert.Equal(t, []string{"worker"}, serviceNamesOf(r.servicesToCreate()))
+
+	r.Services["worker"].CreatedASG = to.Strp("worker-asg")
+	assert.Equal(t, []string{}, serviceNamesOf(r.servicesToCreate()))
+}
+
+func Test_Release_UpdateHealthy_Stages(t *testing.T) {
+	r := mockStagedRelease(t)
+	awsc := mocks.MockAWS()
+	awsc.ASG.AddASG(mocks.MakeMockASG("asg", "project", "config", "web", "1"))
+
+	r.Services["web"].CreatedASG = to.Strp("web-asg")
+	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB))
+
+	// web is healthy so worker is created in the next stage
+	assert.True(t, r.Services["web"].Healthy)
+	assert.Nil(t, r.Services["worker"].HealthReport)
+	assert.False(t, *r.Healthy)
+	assert.True(t, *r.NextStage)
+
+	r.Services["worker"].CreatedASG = to.Strp("worker-asg")
+	assert.NoError(t, r.UpdateHealthy(awsc.ASG, awsc.ELB, awsc.ALB))
+
+	assert.True(t, *r.Healthy)
+	assert.False(t, *r.NextStage)
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/coinbase/step/utils/is"
+	"github.com/coinbase/step/utils/to"
+)
+
+// A release with targets is deployed by the client to every account and region in the list.
+// Each target is deployed as its own release with its own execution, lock and health checks.
+
+// What happens to the other targets when one fails
+const (
+	TargetsStop     = "stop"     // Do not start any more targets, halt running targets
+	TargetsContinue = "continue" // Deploy every target
+)
+
+var targetsOnFailure = []string{TargetsStop, TargetsContinue}
+
+// Target is an account and region to deploy the release to
+type Target struct {
+	AwsAccountID *string `json:"aws_account_id,omitempty"`
+	AwsRegion    *string `json:"aws_region,omitempty"`
+}
+
+// String returns <account_id>/<region>
+func (target *Target) String() string {
+	return fmt.Sprintf("%v/%v", to.Strs(target.AwsAccountID), to.Strs(target.AwsRegion))
+}
+
+// TargetsStopOnFailure returns true unless targets_on_failure is continue
+func (release *Release) TargetsStopOnFailure() bool {
+	return release.TargetsOnFailure == nil || *release.TargetsOnFailure != TargetsContinue
+}
+
+// ValidateTargets returns an error if a target is missing its account or region or is listed twice,
+// or the release's target is not its account and region
+func (release *Release) ValidateTargets() error {
+	if release.Target != nil {
+		current := &Target{AwsAccountID: release.AwsAccountID, AwsRegion: release.AwsRegion}
+		if *release.Target != current.String() {
+			return fieldErrorf("target", "Target %v must be the release's <aws_account_id>/<aws_region> %v", *release.Target, current.String())
+		}
+	}
+
+	if len(release.Targets) == 0 {
+		if release.TargetsParallel != nil || release.TargetsOnFailure != nil {
+			return fieldErrorf("targets", "Targets must be defined to use targets_parallel or targets_on_failure")
+		}
+		return nil
+	}
+
+	if release.Target != nil {
+		return fieldErrorf("targets", "Targets cannot be defined for a target")
+	}
+
+	if release.TargetsOnFailure != nil && !inStrs(targetsOnFailure, *release.TargetsOnFailure) {
+		return fieldErrorf("targets_on_failure", "TargetsOnFailure must be one of %v", targetsOnFailure)
+	}
+
+	seen := []string{}
+	for i, target := range release.Targets {
+		path := indexPath("targets", i)
+		switch {
+		case target == nil:
+			return fieldErrorf(path, "Target is nil")
+		case is.EmptyStr(target.AwsAccountID):
+			return fieldErrorf(joinPath(path, "aws_account_id"), "AwsAccountID must be defined")
+		case is.EmptyStr(target.AwsRegion):
+			return fieldErrorf(joinPath(path, "aws_region"), "AwsRegion must be defined")
+		case inStrs(seen, target.String()):
+			return fieldErrorf(path, "Target %v is listed more than once", target.String())
+		}
+		seen = append(seen, target.String())
+	}
+
+	return nil
+}
+
+// TargetReleases returns a copy of the release for each target
+func (release *Release) TargetReleases() ([]*Release, error) {
+	raw, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := []*Release{}
+	for _, target := range release.Targets {
+		var tr Release
+		if err := json.Unmarshal(raw, &tr); err != nil {
+			return nil, err
+		}
+
+		tr.AwsAccountID = target.AwsAccountID
+		tr.AwsRegion = target.AwsRegion
+		tr.Target = to.Strp(target.String())
+
+		tr.Targets = nil
+		tr.TargetsParallel = nil
+		tr.TargetsOnFailure = nil
+
+		releases = append(releases, &tr)
+	}
+
+	return releases, nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockTargets() []*Target {
+	return []*Target{
+		&Target{AwsAccountID: to.Strp("account"), AwsRegion: to.Strp("us-east-1")},
+		&Target{AwsAccountID: to.Strp("account"), AwsRegion: to.Strp("eu-west-1")},
+	}
+}
+
+func Test_Release_ValidateTargets(t *testing.T) {
+	r := MockRelease(t)
+	assert.NoError(t, r.ValidateTargets())
+
+	r.TargetsParallel = to.Boolp(true)
+	assert.Equal(t, "targets", r.ValidateTargets().(*FieldError).Path)
+
+	r.Targets = mockTargets()
+	assert.NoError(t, r.ValidateTargets())
+
+	r.TargetsOnFailure = to.Strp("ignore")
+	assert.Equal(t, "targets_on_failure", r.ValidateTargets().(*FieldError).Path)
+	r.TargetsOnFailure = to.Strp(TargetsContinue)
+
+	r.Targets[1].AwsRegion = nil
+	assert.Equal(t, "targets[1].aws_region", r.ValidateTargets().(*FieldError).Path)
+
+	r.Targets[1].AwsRegion = to.Strp("us-east-1")
+	err := r.ValidateTargets()
+	assert.Equal(t, "targets[1]: Target account/us-east-1 is listed more than once", err.Error())
+
+	// A target must be the release's account and region
+	tr := MockRelease(t)
+	tr.SetDefaultRegionAccount(to.Strp("us-east-1"), to.Strp("account"))
+	tr.Target = to.Strp("account/us-east-1")
+	assert.NoError(t, tr.ValidateTargets())
+
+	tr.Target = to.Strp("other/us-east-1")
+	assert.Equal(t, "target", tr.ValidateTargets().(*FieldError).Path)
+}
+
+func Test_Release_TargetReleases(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	r.Targets = mockTargets()
+	r.TargetsOnFailure = to.Strp(TargetsContinue)
+
+	assert.False(t, r.TargetsStopOnFailure())
+
+	releases, err := r.TargetReleases()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(releases))
+
+	east, west := releases[0], releases[1]
+	assert.Equal(t, "us-east-1", *east.AwsRegion)
+	assert.Equal(t, "eu-west-1", *west.AwsRegion)
+	assert.Equal(t, "account/us-east-1", *east.Target)
+	assert.Nil(t, east.Targets)
+	assert.Nil(t, east.TargetsOnFailure)
+	assert.Equal(t, *r.Bucket, *east.Bucket)
+
+	// Each target has its own lock
+	assert.Equal(t, "project/config/account/us-east-1/lock", *east.LockPath())
+	assert.Equal(t, "project/config/account/eu-west-1/lock", *west.LockPath())
+
+	// A release deployed directly to a target's account and region shares its lock
+	r.AwsAccountID = to.Strp("account")
+	r.AwsRegion = to.Strp("us-east-1")
+	assert.Equal(t, *east.LockPath(), *r.LockPath())
+
+	// The targets do not share services
+	east.Services["web"].InstanceType = to.Strp("t2.large")
+	assert.Equal(t, "t2.small", *west.Services["web"].InstanceType)
+
+	assert.NoError(t, east.ValidateTargets())
+}
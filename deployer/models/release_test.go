@@ -40,3 +40,53 @@ func Test_Release_ValidateServices_Works(t *testing.T) {
 
 	assert.NoError(t, r.ValidateServices())
 }
+
+func Test_Release_ValidateServices_FieldPath(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+
+	r.Services["web"].Autoscaling.Spread = to.Float64p(2)
+	err := r.ValidateServices()
+	assert.Error(t, err)
+
+	fe, ok := err.(*FieldError)
+	assert.True(t, ok)
+	assert.Equal(t, "services.web.autoscaling.spread", fe.Path)
+	assert.Equal(t, "services.web.autoscaling.spread: Spread must be between 0 and 1", err.Error())
+
+	r = MockRelease(t)
+	MockPrepareRelease(r)
+
+	r.Services["web"].Autoscaling.Policies[1].Type = to.Strp("bad_type")
+	err = r.ValidateServices()
+	assert.Error(t, err)
+	assert.Equal(t, "services.web.autoscaling.policies[1].type", err.(*FieldError).Path)
+
+	r = MockRelease(t)
+	MockPrepareRelease(r)
+
+	r.LifeCycleHooks["TermHook"].Transistion = to.Strp("bad")
+	err = r.ValidateServices()
+	assert.Error(t, err)
+	assert.Equal(t, "lifecycle.TermHook.transition", err.(*FieldError).Path)
+}
+
+func Test_Release_OnlyServices(t *testing.T) {
+	r := mockStagedRelease(t)
+	r.OnlyServices = []*string{to.Strp("worker")}
+	assert.NoError(t, r.ValidateServices())
+
+	r.OnlyServices = []*string{to.Strp("worker"), to.Strp("db")}
+	err := r.ValidateServices()
+	assert.Error(t, err)
+	assert.Equal(t, "only_services[1]", err.(*FieldError).Path)
+
+	r.OnlyServices = []*string{to.Strp("worker"), to.Strp("worker")}
+	assert.Error(t, r.ValidateServices())
+
+	// worker can be created as web is not being deployed
+	r.OnlyServices = []*string{to.Strp("worker")}
+	r.SelectServices()
+	assert.Nil(t, r.Services["web"])
+	assert.Equal(t, []string{"worker"}, serviceNamesOf(r.servicesToCreate()))
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/coinbase/step-asg-deployer/aws/ami"
+)
+
+// schemaEnums are the allowed values of fields, keyed by type and JSON key
+var schemaEnums = map[string][]string{
+	"Policy.type":                   []string{cpuScaleUp, cpuScaleDown},
+	"LifeCycleHook.transition":      []string{launchingTransition, terminatingTransition},
+	"BlockDevice.volume_type":       volumeTypes,
+	"Service.health_check_type":     healthCheckTypes,
+	"Service.placement_tenancy":     placementTenancies,
+	"MetadataOptions.http_tokens":   httpTokens,
+	"MetadataOptions.http_endpoint": httpEndpoints,
+	"Release.targets_on_failure":    targetsOnFailure,
+}
+
+// stringOrObjectTypes can also be written as a string e.g. "ami": "ubuntu"
+var stringOrObjectTypes = map[reflect.Type]bool{
+	reflect.TypeOf(ami.Selector{}): true,
+}
+
+// AcceptsString returns true if the struct type can also be written as a string
+func AcceptsString(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return stringOrObjectTypes[t]
+}
+
+// Schema returns a JSON Schema (draft-07) for a Release
+func Schema() map[string]interface{} {
+	definitions := map[string]interface{}{}
+
+	schema := map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "Release",
+		"definitions": definitions,
+	}
+
+	for k, v := range typeSchema(reflect.TypeOf(Release{}), definitions) {
+		schema[k] = v
+	}
+
+	return schema
+}
+
+func typeSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t, definitions)
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": refOrSchema(t.Elem(), definitions),
+		}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": refOrSchema(t.Elem(), definitions),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+
+	return map[string]interface{}{}
+}
+
+// refOrSchema adds structs to definitions and references them
+func refOrSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return typeSchema(t, definitions)
+	}
+
+	if _, ok := definitions[t.Name()]; !ok {
+		definitions[t.Name()] = map[string]interface{}{} // stop recursion
+		definitions[t.Name()] = structSchema(t, definitions)
+	}
+
+	ref := map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	if AcceptsString(t) {
+		return map[string]interface{}{
+			"oneOf": []interface{}{map[string]interface{}{"type": "string"}, ref},
+		}
+	}
+
+	return ref
+}
+
+func structSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		if enum, ok := schemaEnums[t.Name()+"."+name]; ok {
+			properties[name] = map[string]interface{}{"type": "string", "enum": enum}
+			continue
+		}
+
+		properties[name] = refOrSchema(f.Type, definitions)
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
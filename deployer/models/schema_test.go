@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Schema(t *testing.T) {
+	schema := Schema()
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string"}, properties["project_name"])
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["timeout"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"}, properties["created_at"])
+
+	// ami can be a string or a selector
+	ami := properties["ami"].(map[string]interface{})["oneOf"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "string"}, ami[0])
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/Selector"}, ami[1])
+
+	services := properties["services"].(map[string]interface{})
+	assert.Equal(t, "#/definitions/Service", services["additionalProperties"].(map[string]interface{})["$ref"])
+
+	definitions := schema["definitions"].(map[string]interface{})
+	for _, name := range []string{"Service", "AutoScalingConfig", "Policy", "LifeCycleHook"} {
+		assert.Contains(t, definitions, name)
+	}
+
+	policy := definitions["Policy"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, []string{"cpu_scale_up", "cpu_scale_down"}, policy["type"].(map[string]interface{})["enum"])
+
+	lc := definitions["LifeCycleHook"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, []string{
+		"autoscaling:EC2_INSTANCE_LAUNCHING",
+		"autoscaling:EC2_INSTANCE_TERMINATING",
+	}, lc["transition"].(map[string]interface{})["enum"])
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coinbase/step/utils/to"
+)
+
+// User data can reference secrets with {{ssm:name}} or {{secretsmanager:name}}.
+// The values are never read by the deployer, instead they are fetched on the
+// instance at boot using its profile, so they are not in the launch configuration.
+
+const ssmStore = "ssm"
+const secretsManagerStore = "secretsmanager"
+
+var secretRefRegex = regexp.MustCompile(`{{(ssm|secretsmanager):([a-zA-Z0-9_.\-/]+)}}`)
+
+// SecretRef is a reference to a secret in user data
+type SecretRef struct {
+	Store string
+	Name  string
+}
+
+func hasSecretRefs(userData *string) bool {
+	return userData != nil && secretRefRegex.MatchString(*userData)
+}
+
+// resolveSecretName returns absolute names, relative names are under the secretPath
+func (service *Service) resolveSecretName(name string) string {
+	if strings.HasPrefix(name, "/") {
+		return name
+	}
+	return secretPath(service) + name
+}
+
+// SecretRefs returns the unique secrets referenced in the user data for the service
+func (service *Service) SecretRefs() []*SecretRef {
+	refs := []*SecretRef{}
+	seen := map[SecretRef]bool{}
+
+	for _, match := range secretRefRegex.FindAllStringSubmatch(to.Strs(service.release.UserData), -1) {
+		ref := SecretRef{Store: match[1], Name: service.resolveSecretName(match[2])}
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		refs = append(refs, &ref)
+	}
+
+	return refs
+}
+
+// fetchCommand returns the shell command that reads the secret on the instance
+func (ref *SecretRef) fetchCommand(region string) string {
+	switch ref.Store {
+	case secretsManagerStore:
+		return fmt.Sprintf("aws secretsmanager get-secret-value --region %v --secret-id '%v' --query SecretString --output text", region, ref.Name)
+	default:
+		return fmt.Sprintf("aws ssm get-parameter --region %v --with-decryption --name '%v' --query Parameter.Value --output text", region, ref.Name)
+	}
+}
+
+// withSecrets rewrites secret references into shell variables
+// that are fetched by a preamble after the #! line
+func (service *Service) withSecrets(userData string) string {
+	refs := service.SecretRefs()
+	if len(refs) == 0 {
+		return userData
+	}
+
+	vars := map[SecretRef]string{}
+	preamble := []string{"# Secrets fetched by step-asg-deployer"}
+	for i, ref := range refs {
+		vars[*ref] = fmt.Sprintf("ASGARD_SECRET_%v", i)
+		preamble = append(preamble, fmt.Sprintf(`%v="$(%v)" || exit 1`, vars[*ref], ref.fetchCommand(to.Strs(service.release.AwsRegion))))
+	}
+
+	userData = secretRefRegex.ReplaceAllStringFunc(userData, func(match string) string {
+		m := secretRefRegex.FindStringSubmatch(match)
+		return fmt.Sprintf("${%v}", vars[SecretRef{Store: m[1], Name: service.resolveSecretName(m[2])}])
+	})
+
+	// Validation ensures user data with secrets starts with #!
+	lines := strings.SplitN(userData, "\n", 2)
+	rest := ""
+	if len(lines) == 2 {
+		rest = lines[1]
+	}
+
+	return fmt.Sprintf("%v\n%v\n%v", lines[0], strings.Join(preamble, "\n"), rest)
+}
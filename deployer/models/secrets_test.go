@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_SecretRefs(t *testing.T) {
+	r := MockRelease(t)
+	r.UserData = to.Strp("#!/bin/bash\necho {{ssm:db_password}} {{ssm:db_password}} {{secretsmanager:/project/config/web/api_key}}")
+	MockPrepareRelease(r)
+
+	refs := r.Services["web"].SecretRefs()
+	assert.Equal(t, 2, len(refs))
+	assert.Equal(t, &SecretRef{Store: "ssm", Name: "/project/config/web/db_password"}, refs[0])
+	assert.Equal(t, &SecretRef{Store: "secretsmanager", Name: "/project/config/web/api_key"}, refs[1])
+}
+
+func Test_Service_UserData_Secrets(t *testing.T) {
+	r := MockRelease(t)
+	r.UserData = to.Strp("#!/bin/bash\nexport DB={{ssm:db_password}}\nexport KEY={{secretsmanager:api_key}}\n")
+	MockPrepareRelease(r)
+
+	ud := *r.Services["web"].UserData()
+	lines := strings.Split(ud, "\n")
+
+	assert.Equal(t, "#!/bin/bash", lines[0])
+	assert.Equal(t, `ASGARD_SECRET_0="$(aws ssm get-parameter --region region --with-decryption --name '/project/config/web/db_password' --query Parameter.Value --output text)" || exit 1`, lines[2])
+	assert.Equal(t, `ASGARD_SECRET_1="$(aws secretsmanager get-secret-value --region region --secret-id '/project/config/web/api_key' --query SecretString --output text)" || exit 1`, lines[3])
+	assert.Equal(t, "export DB=${ASGARD_SECRET_0}", lines[4])
+	assert.Equal(t, "export KEY=${ASGARD_SECRET_1}", lines[5])
+	assert.NotContains(t, ud, "{{")
+}
+
+func Test_Release_ValidateAttributes_SecretsRequireScript(t *testing.T) {
+	r := MockRelease(t)
+	r.UserData = to.Strp("#cloud-config\nwrite_files: {{ssm:db_password}}")
+	MockPrepareRelease(r)
+
+	assert.Error(t, r.ValidateAttributes())
+
+	r.UserData = to.Strp("#!/bin/sh\necho {{ssm:db_password}}")
+	assert.NoError(t, r.ValidateAttributes())
+}
+
+func Test_Release_ValidateResources_Secrets(t *testing.T) {
+	r := MockRelease(t)
+	r.UserData = to.Strp("#!/bin/sh\necho {{ssm:db_password}} {{secretsmanager:api_key}}")
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+
+	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
+	assert.NoError(t, err)
+	assert.NoError(t, r.ValidateResources(sm, &DeployerPolicy{}))
+}
+
+func Test_Release_FetchResources_SecretMissing(t *testing.T) {
+	r := MockRelease(t)
+	r.UserData = to.Strp("#!/bin/sh\necho {{ssm:not_there}}")
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+
+	_, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
+	assert.Error(t, err)
+}
+
+func Test_Release_ValidateResources_SecretWrongPath(t *testing.T) {
+	r := MockRelease(t)
+	r.UserData = to.Strp("#!/bin/sh\necho {{ssm:/other/config/web/db_password}}")
+	MockPrepareRelease(r)
+
+	awsc := MockAwsClients(r)
+	awsc.SSM.AddParameter("/other/config/web/db_password")
+
+	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
+	assert.NoError(t, err)
+	assert.Error(t, r.ValidateResources(sm, &DeployerPolicy{}))
+}
@@ -11,21 +11,25 @@ import (
 	"github.com/coinbase/step-asg-deployer/aws/asg"
 	"github.com/coinbase/step-asg-deployer/aws/elb"
 	"github.com/coinbase/step-asg-deployer/aws/iam"
+	"github.com/coinbase/step-asg-deployer/aws/instancetype"
 	"github.com/coinbase/step-asg-deployer/aws/lc"
+	"github.com/coinbase/step-asg-deployer/aws/secretsmanager"
 	"github.com/coinbase/step-asg-deployer/aws/sg"
+	"github.com/coinbase/step-asg-deployer/aws/ssm"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
 )
 
 // HealthReport is built to make log lines like:
 // web: .....|.
-// gray targets, red terminated, yellow unhealthy, green healthy
+// gray targets, red terminated, yellow unhealthy, blue pending hook, green healthy
 type HealthReport struct {
 	TargetHealthy  *int `json:"target_healthy,omitempty"`  // Number of instances aimed to to Launch
 	TargetLaunched *int `json:"target_launched,omitempty"` // Number of instances aimed to to Launch
 	Healthy        *int `json:"healthy,omitempty"`         // Number of instances that are healthy
 	Launching      *int `json:"launching,omitempty"`       // Number of instances that have been created
 	Terminating    *int `json:"terminating,omitempty"`     // Number of instances that are Terminating
+	PendingHook    *int `json:"pending_hook,omitempty"`    // Number of instances waiting for a launching hook
 }
 
 // TYPES
@@ -54,6 +58,29 @@ type Service struct {
 	EBSVolumeType *string `json:"ebs_volume_type,omitempty"`
 	EBSDeviceName *string `json:"ebs_device_name,omitempty"`
 
+	// Additional EBS and instance store volumes
+	BlockDevices []*BlockDevice `json:"block_devices,omitempty"`
+
+	// Instances
+	LaunchReadiness    *LaunchReadiness `json:"launch_readiness,omitempty"`
+	MetadataOptions    *MetadataOptions `json:"metadata_options,omitempty"`
+	DetailedMonitoring *bool            `json:"detailed_monitoring,omitempty"` // 1 minute CloudWatch metrics
+	PlacementTenancy   *string          `json:"placement_tenancy,omitempty"`   // default or dedicated
+
+	// Lines of console output added to the diagnostics of unhealthy instances if the release fails
+	ConsoleOutputLines *int `json:"console_output_lines,omitempty"`
+
+	// ASG
+	HealthCheckType        *string            `json:"health_check_type,omitempty"`         // EC2 or ELB
+	HealthCheckGracePeriod *int64             `json:"health_check_grace_period,omitempty"` // seconds
+	DefaultCooldown        *int64             `json:"default_cooldown,omitempty"`          // seconds
+	TerminationPolicies    []*string          `json:"termination_policies,omitempty"`
+	SuspendedProcesses     []*string          `json:"suspended_processes,omitempty"`
+	MetricsCollection      *MetricsCollection `json:"metrics_collection,omitempty"`
+
+	// Services that must be healthy before this service is created
+	DependsOn []*string `json:"depends_on,omitempty"`
+
 	// Found Resources
 	Resources *ServiceResourceNames `json:"resources,omitempty"`
 
@@ -63,6 +90,7 @@ type Service struct {
 
 	// What is Healthy
 	HealthReport *HealthReport `json:"healthy_report,omitempty"`
+	Diagnostics  *Diagnostics  `json:"diagnostics,omitempty"`
 	Healthy      bool
 }
 
@@ -109,7 +137,7 @@ func (service *Service) UserData() *string {
 	templateARGs = append(templateARGs, "{{CONFIG_NAME}}", to.Strs(service.ConfigName()))
 	replacer := strings.NewReplacer(templateARGs...)
 
-	return to.Strp(replacer.Replace(to.Strs(service.release.UserData)))
+	return to.Strp(service.withSecrets(replacer.Replace(to.Strs(service.release.UserData))))
 }
 
 // LifeCycleHooks returns
@@ -128,6 +156,10 @@ func (service *Service) LifeCycleHookSpecs() []*autoscaling.LifecycleHookSpecifi
 	for _, lc := range service.LifeCycleHooks() {
 		lcs = append(lcs, lc.ToLifecycleHookSpecification())
 	}
+
+	if service.LaunchReadiness != nil {
+		lcs = append(lcs, service.LaunchReadiness.ToLifecycleHookSpecification())
+	}
 	return lcs
 }
 
@@ -143,11 +175,8 @@ func (service *Service) maxTerminations() int {
 	return service.Autoscaling.MaxTerminationsInt()
 }
 
-func (service *Service) errorPrefix() string {
-	if service.ServiceName == nil {
-		return fmt.Sprintf("Service Error:")
-	}
-	return fmt.Sprintf("Service(%v) Error:", *service.ServiceName)
+func (service *Service) maxFailedLaunches() int {
+	return service.Autoscaling.MaxFailedLaunchesInt()
 }
 
 //////////
@@ -168,6 +197,16 @@ func (service *Service) SetDefaults(release *Release, serviceName string) {
 		service.Autoscaling = &AutoScalingConfig{}
 	}
 
+	for _, bd := range service.BlockDevices {
+		if bd != nil {
+			bd.SetDefaults()
+		}
+	}
+
+	if service.LaunchReadiness != nil {
+		service.LaunchReadiness.SetDefaults()
+	}
+
 	if service.Resources == nil {
 		service.Resources = &ServiceResourceNames{
 			Subnets: []*string{to.Strp("place_holder")},
@@ -180,6 +219,7 @@ func (service *Service) SetDefaults(release *Release, serviceName string) {
 // setHealthy sets the health state from the instances
 func (service *Service) setHealthy(instances aws.Instances) {
 	healthy, _, terming := instances.HealthyUnhealthyTerming()
+	pending := len(instances.PendingHookIDs())
 
 	service.HealthReport = &HealthReport{
 		TargetHealthy:  to.Intp(service.target()),
@@ -189,6 +229,10 @@ func (service *Service) setHealthy(instances aws.Instances) {
 		Launching:      to.Intp(len(instances)),
 	}
 
+	if pending > 0 {
+		service.HealthReport.PendingHook = &pending
+	}
+
 	// The Service is Healthy if
 	// the number of instances that are healthy is greater than or equal to the target
 	service.Healthy = healthy >= service.target()
@@ -198,30 +242,19 @@ func (service *Service) setHealthy(instances aws.Instances) {
 // Validate
 //////////
 
-// Validate validates the service
+// Validate validates the service, errors have the path of the field in the service
 func (service *Service) Validate() error {
 	if err := service.ValidateAttributes(); err != nil {
-		return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
-	}
-
-	for name, lc := range service.LifeCycleHooks() {
-		if lc == nil {
-			return fmt.Errorf("LifeCycle %v is nil", name)
-		}
-
-		err := lc.ValidateAttributes()
-		if err != nil {
-			return err
-		}
+		return err
 	}
 
 	// VALIDATE Autoscaling Group Input (this in implemented by AWS)
 	if err := service.createInput().Validate(); err != nil {
-		return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		return inPath("", err)
 	}
 
 	if err := service.createLaunchConfigurationInput().Validate(); err != nil {
-		return fmt.Errorf("%v %v", service.errorPrefix(), err.Error())
+		return inPath("", err)
 	}
 
 	return nil
@@ -230,53 +263,105 @@ func (service *Service) Validate() error {
 // ValidateAttributes validates attributes
 func (service *Service) ValidateAttributes() error {
 	if is.EmptyStr(service.ServiceName) {
-		return fmt.Errorf("ServiceName must be defined")
+		return fieldErrorf("", "ServiceName must be defined")
 	}
 
 	if is.EmptyStr(service.ServiceID) {
-		return fmt.Errorf("ServiceID must be defined")
+		return fieldErrorf("", "ServiceID must be defined")
 	}
 
 	if is.EmptyStr(service.InstanceType) {
-		return fmt.Errorf("InstanceType must be defined")
+		return fieldErrorf("instance_type", "InstanceType must be defined")
 	}
 
 	if service.Autoscaling == nil {
-		return fmt.Errorf("Autoscaling must be defined")
+		return fieldErrorf("autoscaling", "Autoscaling must be defined")
 	}
 
 	if err := service.Autoscaling.ValidateAttributes(); err != nil {
-		return err
+		return inPath("autoscaling", err)
 	}
 
 	// Must have security groups
 	if len(service.SecurityGroups) < 1 {
-		return fmt.Errorf("Security Groups must be included")
+		return fieldErrorf("security_groups", "Security Groups must be included")
 	}
 
 	if !is.UniqueStrp(service.SecurityGroups) {
-		return fmt.Errorf("Security Group must be unique")
+		return fieldErrorf("security_groups", "Security Group must be unique")
 	}
 
 	if !is.UniqueStrp(service.ELBs) {
 		// Non unique string in ELBs or nil value
-		return fmt.Errorf("Non Unique ELBs")
+		return fieldErrorf("elbs", "Non Unique ELBs")
 	}
 
 	if !is.UniqueStrp(service.TargetGroups) {
 		// Non unique string in ELBs or nil value
-		return fmt.Errorf("Non Unique TargetGroups")
+		return fieldErrorf("target_groups", "Non Unique TargetGroups")
+	}
+
+	for i, bd := range service.BlockDevices {
+		path := indexPath("block_devices", i)
+		if bd == nil {
+			return fieldErrorf(path, "BlockDevice nil")
+		}
+
+		if err := bd.ValidateAttributes(); err != nil {
+			return inPath(path, err)
+		}
+	}
+
+	if err := validateBlockDeviceNames(service.BlockDevices, service.ebsDeviceName()); err != nil {
+		return err
+	}
+
+	if service.LaunchReadiness != nil {
+		if err := service.LaunchReadiness.ValidateAttributes(); err != nil {
+			return inPath("launch_readiness", err)
+		}
+	}
+
+	if service.MetadataOptions != nil {
+		if err := service.MetadataOptions.ValidateAttributes(); err != nil {
+			return inPath("metadata_options", err)
+		}
+	}
+
+	if service.ConsoleOutputLines != nil && (*service.ConsoleOutputLines < 0 || *service.ConsoleOutputLines > maxConsoleOutputLines) {
+		return fieldErrorf("console_output_lines", "ConsoleOutputLines must be between 0 and %v", maxConsoleOutputLines)
+	}
+
+	if service.PlacementTenancy != nil && !inStrs(placementTenancies, *service.PlacementTenancy) {
+		return fieldErrorf("placement_tenancy", "PlacementTenancy %q must be one of %v", *service.PlacementTenancy, placementTenancies)
+	}
+
+	if err := service.validateASGAttributes(); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// ebsDeviceName returns the device of the ebs_volume_size volume if there is one
+func (service *Service) ebsDeviceName() *string {
+	if service.EBSVolumeSize == nil {
+		return nil
+	}
+
+	if service.EBSDeviceName == nil {
+		return to.Strp(lc.DefaultDeviceName)
+	}
+
+	return service.EBSDeviceName
+}
+
 //////////
 // Validate Resources
 //////////
 
 // FetchResources attempts to retrieve all resources
-func (service *Service) FetchResources(ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI) (*ServiceResources, error) {
+func (service *Service) FetchResources(ec2 aws.EC2API, elbc aws.ELBAPI, albc aws.ALBAPI, iamc aws.IAMAPI, ssmc aws.SSMAPI, smc aws.SecretsManagerAPI) (*ServiceResources, error) {
 	// RESOURCES THAT ARE PROJECT-CONFIG-SERVICE specific
 	// Fetch Security Group
 	sgs, err := sg.Find(ec2, service.SecurityGroups)
@@ -296,6 +381,12 @@ func (service *Service) FetchResources(ec2 aws.EC2API, elbc aws.ELBAPI, albc aws
 		return nil, err
 	}
 
+	// Fetch Instance Type to check the image architecture
+	instanceType, err := instancetype.Find(ec2, service.InstanceType)
+	if err != nil {
+		return nil, err
+	}
+
 	// FETCH IAM
 	var iamProfile *iam.Profile
 	if service.Profile != nil {
@@ -305,11 +396,34 @@ func (service *Service) FetchResources(ec2 aws.EC2API, elbc aws.ELBAPI, albc aws
 		}
 	}
 
+	// Secrets referenced in user data, only their metadata is fetched
+	params := []*ssm.Parameter{}
+	secrets := []*secretsmanager.Secret{}
+	for _, ref := range service.SecretRefs() {
+		switch ref.Store {
+		case ssmStore:
+			param, err := ssm.Find(ssmc, &ref.Name)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param)
+		case secretsManagerStore:
+			secret, err := secretsmanager.Find(smc, &ref.Name)
+			if err != nil {
+				return nil, err
+			}
+			secrets = append(secrets, secret)
+		}
+	}
+
 	return &ServiceResources{
 		SecurityGroups: sgs,
 		ELBs:           elbs,
 		TargetGroups:   targetGroups,
 		Profile:        iamProfile,
+		InstanceType:   instanceType,
+		Parameters:     params,
+		Secrets:        secrets,
 	}, nil
 }
 
@@ -333,6 +447,10 @@ func (service *Service) CreateResources(asgc aws.ASGAPI, cwc aws.CWAPI) error {
 
 	service.CreatedASG = createdASG.AutoScalingGroupName
 
+	if err := service.configureASG(asgc, createdASG); err != nil {
+		return err
+	}
+
 	if err := service.createAutoScalingPolicies(asgc, cwc); err != nil {
 		return err
 	}
@@ -358,6 +476,11 @@ func (service *Service) createInput() *asg.Input {
 	input.VPCZoneIdentifier = service.SubnetIds()
 	input.LifecycleHookSpecificationList = service.LifeCycleHookSpecs()
 
+	input.HealthCheckType = service.HealthCheckType
+	input.HealthCheckGracePeriod = service.HealthCheckGracePeriod
+	input.DefaultCooldown = service.DefaultCooldown
+	input.TerminationPolicies = service.TerminationPolicies
+
 	for key, value := range service.Tags {
 		input.AddTag(key, value)
 	}
@@ -409,8 +532,19 @@ func (service *Service) createLaunchConfigurationInput() *lc.LaunchConfigInput {
 
 	input.UserData = to.Base64p(service.UserData())
 
+	input.MetadataOptions = service.MetadataOptions.ToInstanceMetadataOptions()
+	input.PlacementTenancy = service.PlacementTenancy
+
+	if service.DetailedMonitoring != nil {
+		input.InstanceMonitoring = &autoscaling.InstanceMonitoring{Enabled: service.DetailedMonitoring}
+	}
+
 	input.AddBlockDevice(service.EBSVolumeSize, service.EBSVolumeType, service.EBSDeviceName)
 
+	for _, bd := range service.BlockDevices {
+		input.AddBlockDeviceMapping(bd.ToBlockDeviceMapping())
+	}
+
 	return input
 }
 
@@ -441,37 +575,63 @@ func (he *HaltError) Error() string {
 // UpdateHealthy updates the health status of the service
 // This might cause a Halt Error which will force the release to stop
 func (service *Service) UpdateHealthy(asgc aws.ASGAPI, elbc aws.ELBAPI, albc aws.ALBAPI) error {
-	all, err := asg.GetInstances(asgc, service.CreatedASG)
+	return updateHealthy([]*Service{service}, asgc, elbc, albc)
+}
+
+// asgHealth returns the ASG instances of the service,
+// errors with a HaltError if instances are terminating or failing to launch
+func (service *Service) asgHealth(asgc aws.ASGAPI) (*healthCheck, error) {
+	asgInstances, err := asg.DescribeInstances(asgc, service.CreatedASG)
 	if err != nil {
-		return err // This might retry
+		return nil, err // This might retry
+	}
+
+	check := &healthCheck{service: service, all: aws.Instances{}, details: aws.InstanceDetails{}}
+	for _, i := range asgInstances {
+		check.all.AddASGInstance(i)
+		check.details.AddASGInstance(i)
 	}
 
 	// Early exit and Halt if there are instances Terminating
-	if terming := all.TerminatingIDs(); len(terming) > service.maxTerminations() {
+	if terming := check.all.TerminatingIDs(); len(terming) > service.maxTerminations() {
 		err := fmt.Errorf("Found terming instances %v, %v", *service.ServiceName, strings.Join(terming, ","))
-		return &HaltError{err} // This will immediately stop deploying
+		return nil, &HaltError{err} // This will immediately stop deploying
 	}
 
-	// Fetch All the instances
-	for _, checkELB := range service.Resources.ELBs {
-		elbInstances, err := elb.GetInstances(elbc, checkELB, all.InstanceIDs())
-		if err != nil {
-			return err // This might retry
+	// Only a service short of instances can be failing to launch them,
+	// this saves a DescribeScalingActivities call per service every check
+	if !service.Healthy && len(check.all) < service.targetCapacity() {
+		if err := service.checkFailedLaunches(asgc); err != nil {
+			return nil, err
 		}
+	}
+
+	return check, nil
+}
 
-		all = all.MergeInstances(elbInstances)
+// checkFailedLaunches returns a HaltError if the ASG cannot launch instances,
+// permanent failures halt immediately others after max_failed_launches
+func (service *Service) checkFailedLaunches(asgc aws.ASGAPI) error {
+	failed, err := asg.FailedLaunches(asgc, service.CreatedASG)
+	if err != nil {
+		return err // This might retry
 	}
 
-	for _, checkTG := range service.Resources.TargetGroups {
-		tgInstances, err := alb.GetInstances(albc, checkTG, all.InstanceIDs())
+	if len(failed) == 0 {
+		return nil
+	}
 
-		if err != nil {
-			return err // This might retry
+	for _, activity := range failed {
+		if asg.PermanentLaunchFailure(activity) {
+			err := fmt.Errorf("Failed to launch instances %v, %v", *service.ServiceName, to.Strs(activity.StatusMessage))
+			return &HaltError{err} // This will immediately stop deploying
 		}
+	}
 
-		all = all.MergeInstances(tgInstances)
+	if len(failed) > service.maxFailedLaunches() {
+		err := fmt.Errorf("Failed to launch instances %v %v times, %v", *service.ServiceName, len(failed), to.Strs(failed[0].StatusMessage))
+		return &HaltError{err}
 	}
 
-	service.setHealthy(all)
 	return nil
 }
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"github.com/coinbase/step-asg-deployer/aws"
+	"github.com/coinbase/step-asg-deployer/aws/asg"
+	"github.com/coinbase/step/utils/is"
+)
+
+// ASG settings that can be set on a service, if they are not
+// set the defaults in asg.Input are used.
+
+var healthCheckTypes = []string{"EC2", "ELB"}
+
+var terminationPolicies = []string{
+	"Default",
+	"OldestInstance",
+	"NewestInstance",
+	"OldestLaunchConfiguration",
+	"ClosestToNextInstanceHour",
+	"AllocationStrategy",
+}
+
+// Launch and AddToLoadBalancer are not included because
+// instances would never become healthy
+var suspendableProcesses = []string{
+	"Terminate",
+	"HealthCheck",
+	"ReplaceUnhealthy",
+	"AZRebalance",
+	"AlarmNotification",
+	"ScheduledActions",
+	"InstanceRefresh",
+}
+
+var groupMetrics = []string{
+	"GroupMinSize",
+	"GroupMaxSize",
+	"GroupDesiredCapacity",
+	"GroupInServiceInstances",
+	"GroupPendingInstances",
+	"GroupStandbyInstances",
+	"GroupTerminatingInstances",
+	"GroupTotalInstances",
+	"GroupInServiceCapacity",
+	"GroupPendingCapacity",
+	"GroupStandbyCapacity",
+	"GroupTerminatingCapacity",
+	"GroupTotalCapacity",
+}
+
+// MetricsCollection enables ASG group metrics in CloudWatch
+type MetricsCollection struct {
+	Metrics []*string `json:"metrics,omitempty"` // All metrics if empty
+}
+
+func (service *Service) validateASGAttributes() error {
+	if service.HealthCheckType != nil && !inStrs(healthCheckTypes, *service.HealthCheckType) {
+		return fieldErrorf("health_check_type", "HealthCheckType %q must be one of %v", *service.HealthCheckType, healthCheckTypes)
+	}
+
+	if service.HealthCheckType != nil && *service.HealthCheckType == "ELB" && len(service.ELBs) == 0 && len(service.TargetGroups) == 0 {
+		return fieldErrorf("health_check_type", "HealthCheckType ELB requires elbs or target_groups")
+	}
+
+	if service.HealthCheckGracePeriod != nil && (*service.HealthCheckGracePeriod < 0 || *service.HealthCheckGracePeriod > 7200) {
+		return fieldErrorf("health_check_grace_period", "HealthCheckGracePeriod must be between 0 and 7200 seconds")
+	}
+
+	if service.DefaultCooldown != nil && *service.DefaultCooldown < 0 {
+		return fieldErrorf("default_cooldown", "DefaultCooldown must be 0 or more seconds")
+	}
+
+	if !is.UniqueStrp(service.TerminationPolicies) {
+		return fieldErrorf("termination_policies", "Non Unique TerminationPolicies")
+	}
+
+	for _, policy := range service.TerminationPolicies {
+		if !inStrs(terminationPolicies, *policy) {
+			return fieldErrorf("termination_policies", "TerminationPolicy %q must be one of %v", *policy, terminationPolicies)
+		}
+	}
+
+	if !is.UniqueStrp(service.SuspendedProcesses) {
+		return fieldErrorf("suspended_processes", "Non Unique SuspendedProcesses")
+	}
+
+	for _, process := range service.SuspendedProcesses {
+		if !inStrs(suspendableProcesses, *process) {
+			return fieldErrorf("suspended_processes", "SuspendedProcess %q must be one of %v", *process, suspendableProcesses)
+		}
+	}
+
+	if service.MetricsCollection != nil {
+		if !is.UniqueStrp(service.MetricsCollection.Metrics) {
+			return fieldErrorf("metrics_collection.metrics", "Non Unique Metrics")
+		}
+
+		for _, metric := range service.MetricsCollection.Metrics {
+			if !inStrs(groupMetrics, *metric) {
+				return fieldErrorf("metrics_collection.metrics", "Metric %q must be one of %v", *metric, groupMetrics)
+			}
+		}
+	}
+
+	return nil
+}
+
+// configureASG sets the ASG settings that cannot be given when it is created
+func (service *Service) configureASG(asgc aws.ASGAPI, group *asg.ASG) error {
+	if err := group.SuspendProcesses(asgc, service.SuspendedProcesses); err != nil {
+		return err
+	}
+
+	if service.MetricsCollection != nil {
+		if err := group.EnableMetricsCollection(asgc, service.MetricsCollection.Metrics); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func inStrs(list []string, str string) bool {
+	for _, el := range list {
+		if el == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/coinbase/step/utils/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Service_ASGAttributes_Validate(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	service := r.Services["web"]
+
+	assert.NoError(t, service.ValidateAttributes())
+
+	service.HealthCheckType = to.Strp("EC3")
+	assert.Equal(t, "health_check_type", service.ValidateAttributes().(*FieldError).Path)
+	service.HealthCheckType = to.Strp("EC2")
+
+	service.HealthCheckGracePeriod = to.Int64p(-1)
+	assert.Equal(t, "health_check_grace_period", service.ValidateAttributes().(*FieldError).Path)
+	service.HealthCheckGracePeriod = to.Int64p(60)
+
+	service.DefaultCooldown = to.Int64p(-1)
+	assert.Equal(t, "default_cooldown", service.ValidateAttributes().(*FieldError).Path)
+	service.DefaultCooldown = to.Int64p(60)
+
+	service.TerminationPolicies = []*string{to.Strp("OldestInstance"), to.Strp("Random")}
+	assert.Equal(t, "termination_policies", service.ValidateAttributes().(*FieldError).Path)
+	service.TerminationPolicies = []*string{to.Strp("OldestInstance"), to.Strp("OldestInstance")}
+	assert.Equal(t, "termination_policies", service.ValidateAttributes().(*FieldError).Path)
+	service.TerminationPolicies = []*string{to.Strp("OldestInstance"), to.Strp("Default")}
+
+	// Launch would stop the deploy from ever being healthy
+	service.SuspendedProcesses = []*string{to.Strp("Launch")}
+	assert.Equal(t, "suspended_processes", service.ValidateAttributes().(*FieldError).Path)
+	service.SuspendedProcesses = []*string{to.Strp("AZRebalance")}
+
+	service.MetricsCollection = &MetricsCollection{Metrics: []*string{to.Strp("GroupCPU")}}
+	assert.Equal(t, "metrics_collection.metrics", service.ValidateAttributes().(*FieldError).Path)
+	service.MetricsCollection = &MetricsCollection{}
+
+	assert.NoError(t, service.ValidateAttributes())
+
+	// ELB health checks need a load balancer
+	service.HealthCheckType = to.Strp("ELB")
+	service.ELBs = nil
+	service.TargetGroups = nil
+	assert.Equal(t, "health_check_type", service.ValidateAttributes().(*FieldError).Path)
+}
+
+func Test_Service_ASGAttributes_CreateInput(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	awsc := MockAwsClients(r)
+
+	sm, err := r.FetchResources(awsc.ASG, awsc.EC2, awsc.ELB, awsc.ALB, awsc.IAM, awsc.SNS, awsc.SQS, awsc.SSM, awsc.SM)
+	assert.NoError(t, err)
+	r.UpdateWithResources(sm)
+
+	service := r.Services["web"]
+
+	// Defaults
+	input := service.createInput()
+	assert.Equal(t, "ELB", *input.HealthCheckType)
+	assert.Equal(t, int64(300), *input.HealthCheckGracePeriod)
+	assert.Equal(t, []string{"ClosestToNextInstanceHour"}, to.StrSlice(input.TerminationPolicies))
+
+	service.HealthCheckType = to.Strp("EC2")
+	service.HealthCheckGracePeriod = to.Int64p(600)
+	service.DefaultCooldown = to.Int64p(60)
+	service.TerminationPolicies = []*string{to.Strp("OldestLaunchConfiguration"), to.Strp("Default")}
+
+	input = service.createInput()
+	assert.Equal(t, "EC2", *input.HealthCheckType)
+	assert.Equal(t, int64(600), *input.HealthCheckGracePeriod)
+	assert.Equal(t, int64(60), *input.DefaultCooldown)
+	assert.Equal(t, []string{"OldestLaunchConfiguration", "Default"}, to.StrSlice(input.TerminationPolicies))
+}
+
+func Test_Service_ASGAttributes_CreateResources(t *testing.T) {
+	r := MockRelease(t)
+	MockPrepareRelease(r)
+	awsc := MockAwsClients(r)
+
+	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW))
+	assert.Equal(t, 0, len(awsc.ASG.SuspendProcessesInputs))
+	assert.Equal(t, 0, len(awsc.ASG.EnableMetricsCollectionInputs))
+
+	r = MockRelease(t)
+	MockPrepareRelease(r)
+	awsc = MockAwsClients(r)
+
+	r.Services["web"].SuspendedProcesses = []*string{to.Strp("AZRebalance")}
+	r.Services["web"].MetricsCollection = &MetricsCollection{}
+
+	assert.NoError(t, r.CreateResources(awsc.ASG, awsc.CW))
+	assert.Equal(t, 1, len(awsc.ASG.SuspendProcessesInputs))
+	assert.Equal(t, []string{"AZRebalance"}, to.StrSlice(awsc.ASG.SuspendProcessesInputs[0].ScalingProcesses))
+	assert.Equal(t, 1, len(awsc.ASG.EnableMetricsCollectionInputs))
+	assert.Nil(t, awsc.ASG.EnableMetricsCollectionInputs[0].Metrics)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/coinbase/step-asg-deployer/aws"
 	"github.com/coinbase/step-asg-deployer/aws/alb"
@@ -9,7 +10,10 @@ import (
 	"github.com/coinbase/step-asg-deployer/aws/asg"
 	"github.com/coinbase/step-asg-deployer/aws/elb"
 	"github.com/coinbase/step-asg-deployer/aws/iam"
+	"github.com/coinbase/step-asg-deployer/aws/instancetype"
+	"github.com/coinbase/step-asg-deployer/aws/secretsmanager"
 	"github.com/coinbase/step-asg-deployer/aws/sg"
+	"github.com/coinbase/step-asg-deployer/aws/ssm"
 	"github.com/coinbase/step-asg-deployer/aws/subnet"
 	"github.com/coinbase/step/utils/is"
 	"github.com/coinbase/step/utils/to"
@@ -27,11 +31,14 @@ type serviceIface interface {
 type ServiceResources struct {
 	Image          *ami.Image
 	Profile        *iam.Profile
+	InstanceType   *instancetype.InstanceType
 	PrevASG        *asg.ASG
 	SecurityGroups []*sg.SecurityGroup
 	ELBs           []*elb.LoadBalancer
 	TargetGroups   []*alb.TargetGroup
 	Subnets        []*subnet.Subnet
+	Parameters     []*ssm.Parameter
+	Secrets        []*secretsmanager.Secret
 }
 
 // ServiceResourceNames struct
@@ -120,6 +127,10 @@ func (sr *ServiceResources) Validate(service *Service) error {
 		return err
 	}
 
+	if err := ValidateInstanceType(service, sr.InstanceType, sr.Image); err != nil {
+		return err
+	}
+
 	// Now the Easy Validations are over time to validate Tags and Paths
 	if err := ValidateIAMProfile(service, sr.Profile); err != nil {
 		return err
@@ -153,6 +164,18 @@ func (sr *ServiceResources) Validate(service *Service) error {
 		}
 	}
 
+	for _, r := range sr.Parameters {
+		if err := ValidateParameter(service, r); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range sr.Secrets {
+		if err := ValidateSecret(service, r); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -181,6 +204,10 @@ func (sr *ServiceResources) validateAttributes(service *Service) error {
 		return fmt.Errorf("Subnets Not Found actual %v expected %v", to.StrSlice(names.Subnets), to.StrSlice(service.Subnets()))
 	}
 
+	if len(service.SecretRefs()) != len(sr.Parameters)+len(sr.Secrets) {
+		return fmt.Errorf("Secrets Not Found actual %v expected %v", len(sr.Parameters)+len(sr.Secrets), len(service.SecretRefs()))
+	}
+
 	return nil
 }
 
@@ -198,6 +225,27 @@ func ValidateImage(service serviceIface, im *ami.Image) error {
 		return fmt.Errorf("Image %v DeployWith Tag expected: %v actual: %v", *im.ImageID, "step-asg-deployer", *im.DeployWithTag)
 	}
 
+	if !im.Available() {
+		return fmt.Errorf("Image %v State expected: %v actual: %v", *im.ImageID, "available", to.Strs(im.State))
+	}
+
+	return nil
+}
+
+// ValidateInstanceType returns
+func ValidateInstanceType(service serviceIface, it *instancetype.InstanceType, im *ami.Image) error {
+	if it == nil {
+		return fmt.Errorf("Instance Type is nil")
+	}
+
+	if im == nil {
+		return fmt.Errorf("Image is nil")
+	}
+
+	if !it.Supports(im.Architecture) {
+		return fmt.Errorf("Image %v Architecture %v not supported by Instance Type %v %v", *im.ImageID, to.Strs(im.Architecture), to.Strs(it.Name), to.StrSlice(it.Architectures))
+	}
+
 	return nil
 }
 
@@ -269,6 +317,39 @@ func ValidateIAMProfile(service serviceIface, profile *iam.Profile) error {
 	return nil
 }
 
+// ValidateParameter returns
+func ValidateParameter(service serviceIface, param *ssm.Parameter) error {
+	if param == nil || param.Name == nil {
+		return fmt.Errorf("SSM Parameter is nil")
+	}
+
+	validPath := secretPath(service)
+	if !strings.HasPrefix(*param.Name, validPath) {
+		return fmt.Errorf("SSM Parameter Path incorrect, it is %q and requires prefix %q", *param.Name, validPath)
+	}
+
+	return nil
+}
+
+// ValidateSecret returns
+func ValidateSecret(service serviceIface, secret *secretsmanager.Secret) error {
+	if secret == nil || secret.Name == nil {
+		return fmt.Errorf("Secret is nil")
+	}
+
+	validPath := secretPath(service)
+	if !strings.HasPrefix(*secret.Name, validPath) {
+		return fmt.Errorf("Secret Path incorrect, it is %q and requires prefix %q", *secret.Name, validPath)
+	}
+
+	return nil
+}
+
+// secretPath returns the path all the services secrets must be under
+func secretPath(service serviceIface) string {
+	return fmt.Sprintf("/%v/%v/%v/", to.Strs(service.ProjectName()), to.Strs(service.ConfigName()), to.Strs(service.Name()))
+}
+
 // ValidateSecurityGroup returns
 func ValidateSecurityGroup(service serviceIface, sc *sg.SecurityGroup) error {
 	if sc == nil {
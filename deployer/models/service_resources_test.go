@@ -8,6 +8,7 @@ import (
 	"github.com/coinbase/step-asg-deployer/aws/asg"
 	"github.com/coinbase/step-asg-deployer/aws/elb"
 	"github.com/coinbase/step-asg-deployer/aws/iam"
+	"github.com/coinbase/step-asg-deployer/aws/instancetype"
 	"github.com/coinbase/step-asg-deployer/aws/sg"
 	"github.com/coinbase/step-asg-deployer/aws/subnet"
 	"github.com/coinbase/step/utils/to"
@@ -38,7 +39,31 @@ func Test_Service_ValidateImage(t *testing.T) {
 	assert.NoError(t, ValidateImage(&MockService{}, &ami.Image{
 		ImageID:       to.Strp("image"),
 		DeployWithTag: to.Strp("step-asg-deployer"),
+		State:         to.Strp("available"),
 	}))
+
+	// Image must be available
+	assert.Error(t, ValidateImage(&MockService{}, &ami.Image{
+		ImageID:       to.Strp("image"),
+		DeployWithTag: to.Strp("step-asg-deployer"),
+		State:         to.Strp("pending"),
+	}))
+}
+
+func Test_Service_ValidateInstanceType(t *testing.T) {
+	// func ValidateInstanceType(service serviceIface, it *instancetype.InstanceType, im *ami.Image) error {
+	im := &ami.Image{ImageID: to.Strp("image"), Architecture: to.Strp("arm64")}
+	assert.Error(t, ValidateInstanceType(&MockService{}, nil, im))
+
+	assert.Error(t, ValidateInstanceType(&MockService{}, &instancetype.InstanceType{
+		Name:          to.Strp("t2.small"),
+		Architectures: []*string{to.Strp("i386"), to.Strp("x86_64")},
+	}, im))
+
+	assert.NoError(t, ValidateInstanceType(&MockService{}, &instancetype.InstanceType{
+		Name:          to.Strp("m6g.large"),
+		Architectures: []*string{to.Strp("arm64")},
+	}, im))
 }
 
 func Test_Service_ValidateSubnet(t *testing.T) {
@@ -22,7 +22,8 @@ project = project('coinbase', 'step-asg-deployer') {
   }
 }
 
-step_name = "#{project.org}-#{project.name}"
+# DEPLOYER_NAME runs an independent deployer in the same account e.g. for non-production
+step_name = ENV.fetch('DEPLOYER_NAME', "#{project.org}-#{project.name}")
 step_role_name =  "#{step_name}-step-function-role"
 lambda_role_name = "#{step_name}-lambda-role"
 lambda_assumed_role_name = "#{step_name}-assumed"
@@ -212,9 +213,11 @@ project.resource('aws_iam_role_policy', lambda_assumed_role_name) {
             "iam:GetInstanceProfile",
 
             "ec2:DescribeImages",
+            "ec2:DescribeInstanceTypes",
             "ec2:RunInstances",
             "ec2:DescribeSubnets",
             "ec2:DescribeSecurityGroups",
+            "ec2:GetConsoleOutput",
 
             "elasticloadbalancing:DescribeLoadBalancerAttributes",
             "elasticloadbalancing:DescribeLoadBalancers",
@@ -231,6 +234,10 @@ project.resource('aws_iam_role_policy', lambda_assumed_role_name) {
             "cloudwatch:DescribeAlarms",
 
             "sns:GetTopicAttributes",
+            "sqs:GetQueueUrl",
+
+            "ssm:DescribeParameters",
+            "secretsmanager:DescribeSecret",
 
             "autoscaling:*"
           ],
@@ -256,8 +263,17 @@ lambda_function = project.resource("aws_lambda_function", step_name) {
 
   role lambda_role.to_ref('arn')
 
+  environment {
+    variables({
+      "STEP_ASG_DEPLOYER_NAME" => step_name,
+      "STEP_ASG_DEPLOYER_BUCKET" => s3_bucket_name,
+      "STEP_ASG_DEPLOYER_ASSUMED_ROLE" => lambda_assumed_role_name,
+    })
+  }
+
   lifecycle {
-    ignore_changes ["environment", "filename", "source_code_hash"]
+    # The code is uploaded by the deploy script, the environment is the deployer's identity so terraform manages it
+    ignore_changes ["filename", "source_code_hash"]
   }
 
   filename File.expand_path(File.dirname(__FILE__)) + '/lambda.zip'
@@ -268,3 +284,31 @@ lambda_function = project.resource("aws_lambda_function", step_name) {
   publish "true"
 }
 
+
+########################################
+###            GC                    ###
+########################################
+
+# Daily GC of launch configurations and alarms left behind by deleted ASGs
+gc_rule = project.resource("aws_cloudwatch_event_rule", "#{step_name}-gc") {
+  name                "#{step_name}-gc"
+  description         "Delete orphaned launch configurations and alarms"
+  schedule_expression "rate(1 day)"
+}
+
+project.resource("aws_cloudwatch_event_target", "#{step_name}-gc") {
+  depends_on [gc_rule.terraform_name, lambda_function.terraform_name]
+  rule      "#{step_name}-gc"
+  target_id "#{step_name}-gc"
+  arn       lambda_function.to_ref("arn")
+  input     '{"Task": "GC"}'
+}
+
+project.resource("aws_lambda_permission", "#{step_name}-gc") {
+  depends_on [gc_rule.terraform_name, lambda_function.terraform_name]
+  statement_id  "#{step_name}-gc"
+  action        "lambda:InvokeFunction"
+  function_name step_name
+  principal     "events.amazonaws.com"
+  source_arn    gc_rule.to_ref("arn")
+}
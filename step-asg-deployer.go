@@ -1,28 +1,73 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
+	"github.com/coinbase/step-asg-deployer/aws/gc"
 	"github.com/coinbase/step-asg-deployer/deployer"
 	"github.com/coinbase/step-asg-deployer/deployer/client"
 	"github.com/coinbase/step/utils/run"
 )
 
 func main() {
-	var arg, command string
-	switch len(os.Args) {
-	case 1:
+	if len(os.Args) == 1 {
 		fmt.Println("Starting Lambda")
 		run.LambdaTasks(deployer.TaskFunctions())
-	case 2:
-		command = os.Args[1]
+	}
+
+	command := os.Args[1]
+
+	switch command {
+	case "-h", "-help", "--help", "help":
+		printUsage()
+		os.Exit(0)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	output := flags.String("output", client.OutputText, "Output format: text, plain or json")
+	config := flags.String("config", "", "Config overlay merged into the release e.g. production for release.production.json")
+	services := flags.String("services", "", "Comma separated services to deploy e.g. web,api, the other services are left untouched")
+	yes := flags.Bool("yes", false, "Reconcile without asking for confirmation")
+	maxAge := flags.Duration("max-age", gc.DefaultMaxAge, "GC only deletes resources older than this e.g. 48h")
+	dryRun := flags.Bool("dry-run", false, "GC prints what it would delete without deleting")
+	deployerName := flags.String("deployer", "", "Name of the deployer's Lambda and Step Function, default coinbase-step-asg-deployer")
+	bucket := flags.String("bucket", "", "Bucket of the deployer, default <deployer>-<account_id>")
+	identityFile := flags.String("identity", "", "JSON file with the deployer's name and bucket e.g. {\"name\": \"...\", \"bucket\": \"...\"}")
+	keepRelease := flags.String("keep-release", "", "Release whose ASGs reconcile keeps, default the latest succeeded release")
+	reason := flags.String("reason", "", "Why the release is halted, shown in the release's error")
+	haltedBy := flags.String("halted-by", os.Getenv("USER"), "Who halted the release, default $USER")
+	releaseID := flags.String("release-id", "", "Only halt the release with this ID")
+	flags.Usage = printUsage
+	if err := flags.Parse(os.Args[2:]); err != nil {
+		// Usage was printed, only an explicit -h is not an error
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(client.ExitError)
+	}
+
+	if err := client.ValidOutput(*output); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(client.ExitError)
+	}
+
+	identity, err := client.Identity(identityFile, deployerName, bucket)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(client.ExitError)
+	}
+
+	var arg string
+	switch flags.NArg() {
+	case 0:
 		arg = ""
-	case 3:
-		command = os.Args[1]
-		arg = os.Args[2]
+	case 1:
+		arg = flags.Arg(0)
 	default:
 		printUsage() // Print how to use and exit
+		os.Exit(client.ExitError)
 	}
 
 	switch command {
@@ -33,23 +78,50 @@ func main() {
 	case "deploy":
 		// Send Configuration to the deployer
 		// arg is a filename OR a JSON string
-		err := client.Deploy(&arg)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
-		}
+		exitOnError(client.Deploy(&arg, config, services, identity, *output))
 	case "halt":
-		err := client.Halt(&arg)
-		if err != nil {
-			fmt.Println(err.Error())
-			os.Exit(1)
+		// --services only halts releases deploying one of the services
+		exitOnError(client.Halt(&arg, config, services, client.NewHaltRequest(reason, haltedBy, releaseID), identity, *output))
+	case "pause":
+		// Hold a running release in WaitForHealthy
+		exitOnError(client.Pause(&arg, config, identity))
+	case "resume":
+		exitOnError(client.Resume(&arg, config, identity))
+	case "reconcile":
+		// Recover from FailureDirty by tearing down all but the current ASGs
+		exitOnError(client.Reconcile(&arg, config, identity, keepRelease, *yes))
+	case "gc":
+		// Delete launch configurations and alarms left behind by deleted ASGs
+		exitOnError(client.GC(*maxAge, *dryRun, *output))
+	case "schema":
+		schema, err := client.Schema()
+		exitOnError(err)
+		fmt.Println(schema)
+	case "validate":
+		// Checks the release without AWS credentials
+		if err := client.Validate(&arg, config, identity); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(client.ExitBadRelease)
 		}
+		fmt.Println("Release is valid")
 	default:
 		printUsage() // Print how to use and exit
+		os.Exit(client.ExitError)
 	}
 }
 
+// exitOnError exits with a code that lets pipelines tell failures apart
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+
+	// stderr keeps json output parseable
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(client.ExitCode(err))
+}
+
+// printUsage prints to stderr so it is not mistaken for the output of a command
 func printUsage() {
-	fmt.Println("Usage: step-asg-deployer <json|exec|deploy> <arg> (No args starts Lambda)")
-	os.Exit(0)
+	fmt.Fprintln(os.Stderr, "Usage: step-asg-deployer <json|exec|deploy|halt|pause|resume|reconcile|gc|schema|validate> [--output text|plain|json] [--config name] [--services web,api] [--yes] [--keep-release id] [--max-age 24h] [--dry-run] [--deployer name] [--bucket name] [--identity file] [--reason text] [--halted-by name] [--release-id id] <arg> (No args starts Lambda)")
 }